@@ -3,10 +3,12 @@ module github.com/vampire/je
 go 1.24.5
 
 require (
+	github.com/pelletier/go-toml/v2 v2.2.3
 	github.com/sergi/go-diff v1.4.0
 	github.com/spf13/cobra v1.9.1
 	github.com/tidwall/gjson v1.18.0
 	github.com/tidwall/sjson v1.2.5
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"text/tabwriter"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+// FileStatus is the outcome of processing one file with --each.
+type FileStatus int
+
+const (
+	StatusChanged   FileStatus = iota // The file was edited
+	StatusUnchanged                   // The assignments left the file as it was
+	StatusFailed                      // Processing or writing the file failed
+	StatusSkipped                     // Not processed because an earlier file failed
+)
+
+func (s FileStatus) String() string {
+	switch s {
+	case StatusChanged:
+		return "changed"
+	case StatusUnchanged:
+		return "unchanged"
+	case StatusFailed:
+		return "failed"
+	default:
+		return "skipped"
+	}
+}
+
+// EachOptions controls how ProcessEach processes multiple files.
+type EachOptions struct {
+	Jobs      int  // Number of files processed concurrently; 1 if zero or less
+	KeepGoing bool // Continue with remaining files after a failure
+	Process   ProcessOptions
+
+	// Output writes each result to this path template (see OutputPath)
+	// instead of editing files in place.
+	Output string
+}
+
+// FileResult holds the outcome for one file.
+type FileResult struct {
+	File   string
+	Status FileStatus
+	Err    error
+
+	// Conflicts lists assignments whose effect depends on their order,
+	// as reported for the file in ProcessResult.Conflicts.
+	Conflicts []parser.Conflict
+}
+
+// EachSummary holds the outcome for every file, in the order given.
+type EachSummary struct {
+	Files []FileResult
+}
+
+// ProcessEach applies assignments to each file independently using a pool
+// of opts.Jobs workers, editing files in place or writing to opts.Output.
+// Without KeepGoing no new files are started after the first failure;
+// files not started are reported as skipped.
+func ProcessEach(files []string, assignments []parser.Assignment, opts EachOptions) *EachSummary {
+	jobs := opts.Jobs
+	if jobs < 1 {
+		jobs = 1
+	}
+
+	summary := &EachSummary{Files: make([]FileResult, len(files))}
+	for i, file := range files {
+		summary.Files[i] = FileResult{File: file, Status: StatusSkipped}
+	}
+	if opts.Output != "" && len(files) > 1 && !IsOutputTemplate(opts.Output) {
+		err := fmt.Errorf("output %q has no placeholders, so every file would overwrite it", opts.Output)
+		for i := range summary.Files {
+			summary.Files[i].Status, summary.Files[i].Err = StatusFailed, err
+		}
+		return summary
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed bool
+	)
+	indices := make(chan int)
+	for range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				mu.Lock()
+				stop := failed && !opts.KeepGoing
+				mu.Unlock()
+				if stop {
+					continue
+				}
+
+				result := processEachFile(files[i], opts.Output, assignments, opts.Process)
+				mu.Lock()
+				summary.Files[i] = result
+				if result.Status == StatusFailed {
+					failed = true
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for i := range files {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	return summary
+}
+
+func processEachFile(file, output string, assignments []parser.Assignment, opts ProcessOptions) FileResult {
+	result, err := EditFile(file, output, assignments, opts)
+	if err != nil {
+		return FileResult{File: file, Status: StatusFailed, Err: err}
+	}
+	if bytes.Equal(result.Original, result.Modified) {
+		return FileResult{File: file, Status: StatusUnchanged, Conflicts: result.Conflicts}
+	}
+	return FileResult{File: file, Status: StatusChanged, Conflicts: result.Conflicts}
+}
+
+// Count returns the number of files with the given status.
+func (s *EachSummary) Count(status FileStatus) int {
+	n := 0
+	for _, f := range s.Files {
+		if f.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// ExitCode returns 0 when every file succeeded, 1 when none did and 2 when
+// only some did.
+func (s *EachSummary) ExitCode() int {
+	succeeded := s.Count(StatusChanged) + s.Count(StatusUnchanged)
+	switch succeeded {
+	case len(s.Files):
+		return 0
+	case 0:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// WriteTable writes a per-file status table followed by totals.
+func (s *EachSummary) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "FILE\tSTATUS\tERROR")
+	for _, f := range s.Files {
+		errMsg := ""
+		if f.Err != nil {
+			// Keep one row per file; syntax errors carry a multi-line snippet
+			errMsg, _, _ = strings.Cut(f.Err.Error(), "\n")
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", f.File, f.Status, errMsg)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, "%d changed, %d unchanged, %d failed, %d skipped\n",
+		s.Count(StatusChanged), s.Count(StatusUnchanged), s.Count(StatusFailed), s.Count(StatusSkipped))
+	return err
+}
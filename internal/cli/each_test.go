@@ -0,0 +1,133 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestProcessEach(t *testing.T) {
+	tests := []struct {
+		name      string
+		keepGoing bool
+		want      []FileStatus
+	}{
+		{"fail fast", false, []FileStatus{StatusChanged, StatusUnchanged, StatusFailed, StatusSkipped}},
+		{"keep going", true, []FileStatus{StatusChanged, StatusUnchanged, StatusFailed, StatusChanged}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := writeFiles(t, dir, []string{"a.json", "b.json", "c.json", "d.json"},
+				[]string{`{"port":1}`, `{"port":80}`, `{"port":`, `{"port":4}`})
+
+			summary := ProcessEach(files, setPort, EachOptions{Jobs: 1, KeepGoing: tt.keepGoing})
+			wantStatuses(t, summary, tt.want...)
+			if summary.Files[2].Err == nil {
+				t.Error("failed file has no error")
+			}
+			if tt.keepGoing {
+				wantContents(t, files[3], `{"port":80}`)
+			} else {
+				wantContents(t, files[3], `{"port":4}`)
+			}
+		})
+	}
+}
+
+func TestProcessEachConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	names := make([]string, 20)
+	contents := make([]string, len(names))
+	for i := range names {
+		names[i] = fmt.Sprintf("%02d.json", i)
+		contents[i] = fmt.Sprintf(`{"port":%d}`, i)
+	}
+	files := writeFiles(t, dir, names, contents)
+
+	summary := ProcessEach(files, setPort, EachOptions{Jobs: 4})
+	for i, f := range summary.Files {
+		// Results stay in input order whatever order files finish in
+		if f.File != files[i] {
+			t.Fatalf("Files[%d] = %s, want %s", i, f.File, files[i])
+		}
+	}
+	if n := summary.Count(StatusChanged) + summary.Count(StatusUnchanged); n != len(files) {
+		t.Errorf("%d files succeeded, want %d", n, len(files))
+	}
+	for _, file := range files {
+		wantContents(t, file, `{"port":80}`)
+	}
+}
+
+func TestProcessEachReportsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "b.json"}, []string{`{"port":1}`, `{"port":80}`})
+	assignments, err := parser.ParseAssignments([]string{"port:=8080", "port:=80"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary := ProcessEach(files, assignments, EachOptions{Jobs: 1})
+	wantStatuses(t, summary, StatusChanged, StatusUnchanged)
+	for _, f := range summary.Files {
+		if len(f.Conflicts) != 1 || f.Conflicts[0].Kind != parser.ConflictOverwrite {
+			t.Errorf("%s conflicts = %v, want one overwrite", filepath.Base(f.File), f.Conflicts)
+		}
+	}
+}
+
+func TestProcessEachOutputNeedsPlaceholder(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "b.json"}, []string{`{}`, `{}`})
+
+	summary := ProcessEach(files, setPort, EachOptions{Output: filepath.Join(dir, "out.json")})
+	wantStatuses(t, summary, StatusFailed, StatusFailed)
+	wantContents(t, filepath.Join(dir, "out.json"), "")
+}
+
+func TestEachSummaryExitCode(t *testing.T) {
+	tests := []struct {
+		statuses []FileStatus
+		want     int
+	}{
+		{[]FileStatus{StatusChanged, StatusUnchanged}, 0},
+		{nil, 0},
+		{[]FileStatus{StatusFailed, StatusSkipped}, 1},
+		{[]FileStatus{StatusChanged, StatusFailed, StatusSkipped}, 2},
+	}
+	for _, tt := range tests {
+		summary := &EachSummary{}
+		for _, status := range tt.statuses {
+			summary.Files = append(summary.Files, FileResult{Status: status})
+		}
+		if got := summary.ExitCode(); got != tt.want {
+			t.Errorf("ExitCode() for %v = %d, want %d", tt.statuses, got, tt.want)
+		}
+	}
+}
+
+func TestEachSummaryWriteTable(t *testing.T) {
+	summary := &EachSummary{Files: []FileResult{
+		{File: "a.json", Status: StatusChanged},
+		{File: "config/b.json", Status: StatusFailed, Err: errors.New("invalid JSON at line 1\n  1 | {\n    ^")},
+		{File: "c.json", Status: StatusSkipped},
+	}}
+
+	var buf bytes.Buffer
+	if err := summary.WriteTable(&buf); err != nil {
+		t.Fatalf("WriteTable() error = %v", err)
+	}
+	want := "FILE           STATUS   ERROR\n" +
+		"a.json         changed  \n" +
+		"config/b.json  failed   invalid JSON at line 1\n" +
+		"c.json         skipped  \n" +
+		"1 changed, 0 unchanged, 1 failed, 1 skipped\n"
+	if buf.String() != want {
+		t.Errorf("WriteTable() =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
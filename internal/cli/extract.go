@@ -0,0 +1,16 @@
+package cli
+
+import (
+	"github.com/vampire/je/internal/json"
+	"github.com/vampire/je/internal/operations"
+)
+
+// ExtractFile returns the value at path in a file, decoded per decode, as
+// printed by `je file.json --extract path [--decode base64|hex]`.
+func ExtractFile(filename, path, decode string, format json.FileFormat) ([]byte, error) {
+	data, err := ReadDocument(filename, format, false)
+	if err != nil {
+		return nil, err
+	}
+	return operations.ExtractValue(data, path, decode)
+}
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/vampire/je/internal/json"
@@ -9,11 +10,47 @@ import (
 
 // ReadJSONFile reads JSON from a file or stdin, creating an empty object if --create is set.
 func ReadJSONFile(filename string, createIfMissing bool) ([]byte, error) {
-	data, err := json.ReadFile(filename)
+	return ReadDocument(filename, json.FormatAuto, createIfMissing)
+}
+
+// ReadDocument reads a file or stdin in the given format and returns it as JSON,
+// creating an empty object if --create is set.
+func ReadDocument(filename string, format json.FileFormat, createIfMissing bool) ([]byte, error) {
+	source, err := readSource(filename, createIfMissing)
 	if err != nil {
-		if os.IsNotExist(err) && createIfMissing {
-			return []byte("{}"), nil
+		return nil, err
+	}
+	return decodeSource(filename, source, format)
+}
+
+// readSource returns the raw contents of a file or stdin. A missing file
+// yields nil if createIfMissing is set.
+func readSource(filename string, createIfMissing bool) ([]byte, error) {
+	if filename == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read from stdin: %w", err)
 		}
+		return data, nil
+	}
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) && createIfMissing {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
+// decodeSource converts raw file contents to JSON. A nil source, for a file
+// that does not exist yet, is an empty object.
+func decodeSource(filename string, source []byte, format json.FileFormat) ([]byte, error) {
+	if source == nil {
+		return []byte("{}"), nil
+	}
+	data, err := json.Decode(source, json.ResolveFormat(filename, format))
+	if err != nil {
 		if filename == "-" {
 			return nil, fmt.Errorf("failed to read from stdin: %w", err)
 		}
@@ -30,3 +67,10 @@ func GetFilePermissions(filename string) os.FileMode {
 	return 0644
 }
 
+// sourceName returns the name used for a file in error messages.
+func sourceName(filename string) string {
+	if filename == "-" {
+		return "<stdin>"
+	}
+	return filename
+}
@@ -0,0 +1,15 @@
+package cli
+
+import (
+	"github.com/vampire/je/internal/json"
+)
+
+// HashFile returns the SHA-256 of the canonical (RFC 8785) form of a file, or
+// of the value at path within it, as printed by `je hash file.json [path]`.
+func HashFile(filename, path string, format json.FileFormat) (string, error) {
+	data, err := ReadDocument(filename, format, false)
+	if err != nil {
+		return "", err
+	}
+	return json.Hash(data, path)
+}
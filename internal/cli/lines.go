@@ -0,0 +1,200 @@
+package cli
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tidwall/gjson"
+	jsonfile "github.com/vampire/je/internal/json"
+	"github.com/vampire/je/internal/operations"
+	"github.com/vampire/je/internal/parser"
+)
+
+// LinesOptions controls JSON Lines processing.
+type LinesOptions struct {
+	// Filter restricts edits to records matching every filter; other records
+	// are copied through unchanged. See ParseFilter.
+	Filter []parser.Assignment
+
+	// Operations controls how assignments are applied to each record.
+	Operations operations.Options
+}
+
+// LinesResult summarizes a JSON Lines run.
+type LinesResult struct {
+	Records int // Number of JSON records read
+	Edited  int // Number of records the assignments were applied to
+}
+
+// ParseFilter parses record filters. `path=value` matches records where path
+// holds the string value, `path:=json` matches records where path equals the
+// JSON value, and `path:=` matches records where path does not exist.
+func ParseFilter(args []string) ([]parser.Assignment, error) {
+	filters, err := parser.ParseAssignments(args)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range filters {
+		if f.Operator != parser.OpAssignString && f.Operator != parser.OpAssignJSON {
+			return nil, fmt.Errorf("invalid filter %q: only = and := are supported", f.Path)
+		}
+		if f.Operator == parser.OpAssignJSON && f.Value != "" && !json.Valid([]byte(f.Value)) {
+			return nil, fmt.Errorf("invalid filter %q: value is not valid JSON", f.Path)
+		}
+	}
+	return filters, nil
+}
+
+// ProcessJSONLines applies assignments to each newline-delimited record read
+// from r and writes the records to w one at a time, so memory use does not
+// grow with the input. Blank lines and line endings are preserved.
+func ProcessJSONLines(r io.Reader, w io.Writer, assignments []parser.Assignment, opts LinesOptions) (*LinesResult, error) {
+	reader := bufio.NewReader(r)
+	writer := bufio.NewWriter(w)
+	result := &LinesResult{}
+
+	for lineNo := 1; ; lineNo++ {
+		line, readErr := reader.ReadBytes('\n')
+		if readErr != nil && !errors.Is(readErr, io.EOF) {
+			return result, fmt.Errorf("failed to read line %d: %w", lineNo, readErr)
+		}
+		if len(line) == 0 {
+			break
+		}
+
+		record, ending := splitLineEnding(line)
+		if len(bytes.TrimSpace(record)) > 0 {
+			edited, err := processRecord(record, assignments, opts, result)
+			if err != nil {
+				return result, fmt.Errorf("line %d: %w", lineNo, err)
+			}
+			record = edited
+		}
+
+		if _, err := writer.Write(record); err != nil {
+			return result, fmt.Errorf("failed to write output: %w", err)
+		}
+		if _, err := writer.Write(ending); err != nil {
+			return result, fmt.Errorf("failed to write output: %w", err)
+		}
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		return result, fmt.Errorf("failed to write output: %w", err)
+	}
+	return result, nil
+}
+
+// ProcessJSONLinesFile streams a JSON Lines file (or stdin) through
+// ProcessJSONLines. Output goes to outputFile, or replaces the input when
+// outputFile is empty. Files are written atomically as by
+// jsonfile.AtomicWriteFile.
+func ProcessJSONLinesFile(filename, outputFile string, assignments []parser.Assignment, opts LinesOptions) (*LinesResult, error) {
+	var in io.Reader = os.Stdin
+	if filename != "-" {
+		f, err := os.Open(filename)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	output := filename
+	if outputFile != "" {
+		output = outputFile
+	}
+	if output == "-" {
+		return ProcessJSONLines(in, os.Stdout, assignments, opts)
+	}
+
+	// Records are streamed into the staged file, which replaces the
+	// output only once every record has been processed
+	var result *LinesResult
+	var processErr error
+	staged, err := jsonfile.StageStream(output, GetFilePermissions(filename), jsonfile.WriteOptions{}, func(w io.Writer) error {
+		result, processErr = ProcessJSONLines(in, w, assignments, opts)
+		return processErr
+	})
+	if processErr != nil {
+		return result, processErr
+	}
+	if err != nil {
+		return result, fmt.Errorf("failed to write output: %w", err)
+	}
+	if err := staged.Commit(); err != nil {
+		staged.Abort()
+		return result, fmt.Errorf("failed to write output: %w", err)
+	}
+	return result, nil
+}
+
+func processRecord(record []byte, assignments []parser.Assignment, opts LinesOptions, result *LinesResult) ([]byte, error) {
+	if !json.Valid(record) {
+		return nil, errors.New("invalid JSON record")
+	}
+	result.Records++
+
+	if !MatchesFilter(record, opts.Filter) {
+		return record, nil
+	}
+
+	edited, err := operations.ApplyAssignmentsWithOptions(record, assignments, opts.Operations)
+	if err != nil {
+		return nil, err
+	}
+	result.Edited++
+
+	// Embedded values may span lines; records must stay on one.
+	if bytes.ContainsAny(edited, "\r\n") {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, edited); err != nil {
+			return nil, err
+		}
+		edited = buf.Bytes()
+	}
+	return edited, nil
+}
+
+// MatchesFilter reports whether a record satisfies every filter.
+func MatchesFilter(record []byte, filters []parser.Assignment) bool {
+	for _, f := range filters {
+		value := gjson.GetBytes(record, f.Path)
+		switch {
+		case f.Operator == parser.OpAssignString:
+			if value.Type != gjson.String || value.Str != f.Value {
+				return false
+			}
+		case f.Value == "":
+			if value.Exists() {
+				return false
+			}
+		default:
+			if !value.Exists() || !jsonfile.EqualJSON([]byte(value.Raw), []byte(f.Value)) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// splitLineEnding separates a line from its \n or \r\n terminator.
+func splitLineEnding(line []byte) (record, ending []byte) {
+	switch {
+	case bytes.HasSuffix(line, []byte("\r\n")):
+		return line[:len(line)-2], line[len(line)-2:]
+	case bytes.HasSuffix(line, []byte("\n")):
+		return line[:len(line)-1], line[len(line)-1:]
+	default:
+		return line, nil
+	}
+}
@@ -0,0 +1,146 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestMatchesFilter(t *testing.T) {
+	record := []byte(`{"level":"error","code":500,"tags":["a","b"],"user":{"id":1}}`)
+
+	tests := []struct {
+		filter []string
+		want   bool
+	}{
+		{[]string{"level=error"}, true},
+		{[]string{"level=warn"}, false},
+		{[]string{"code=500"}, false}, // = only matches strings
+		{[]string{"code:=500"}, true},
+		{[]string{"code:=500.0"}, false},
+		{[]string{"tags:=[\"a\", \"b\"]"}, true},
+		{[]string{`level:="\u0065rror"`}, true}, // strings compare by value, not escaping
+		{[]string{"user:={ \"id\": 1 }"}, true},
+		{[]string{"missing:="}, true},
+		{[]string{"level:="}, false},
+		{[]string{"level=error", "code:=404"}, false},
+		{nil, true},
+	}
+	for _, tt := range tests {
+		filter, err := ParseFilter(tt.filter)
+		if err != nil {
+			t.Fatalf("ParseFilter(%q) error = %v", tt.filter, err)
+		}
+		if got := MatchesFilter(record, filter); got != tt.want {
+			t.Errorf("MatchesFilter(%q) = %v, want %v", tt.filter, got, tt.want)
+		}
+	}
+
+	for _, invalid := range []string{"tags[]=c", "code:=nope"} {
+		if _, err := ParseFilter([]string{invalid}); err == nil {
+			t.Errorf("ParseFilter(%q) succeeded, want error", invalid)
+		}
+	}
+}
+
+func TestProcessJSONLines(t *testing.T) {
+	input := "{\"level\":\"error\"}\r\n\n{\"level\":\"info\"}\r\n  \n{\"level\":\"error\"}"
+	filter, err := ParseFilter([]string{"level=error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignments := []parser.Assignment{{Path: "seen", Operator: parser.OpAssignJSON, Value: "true"}}
+
+	var out bytes.Buffer
+	result, err := ProcessJSONLines(strings.NewReader(input), &out, assignments, LinesOptions{Filter: filter})
+	if err != nil {
+		t.Fatalf("ProcessJSONLines() error = %v", err)
+	}
+
+	// Blank lines and each line's ending, including a missing final one, are kept
+	want := "{\"level\":\"error\",\"seen\":true}\r\n\n{\"level\":\"info\"}\r\n  \n{\"level\":\"error\",\"seen\":true}"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+	if result.Records != 3 || result.Edited != 2 {
+		t.Errorf("result = %+v, want 3 records with 2 edited", *result)
+	}
+}
+
+func TestProcessJSONLinesCompactsMultiLineValues(t *testing.T) {
+	assignments := []parser.Assignment{{Path: "data", Operator: parser.OpAssignJSON, Value: "{\n  \"a\": [1,\n 2]\n}"}}
+
+	var out bytes.Buffer
+	if _, err := ProcessJSONLines(strings.NewReader("{\"id\":1}\n{\"id\":2}\n"), &out, assignments, LinesOptions{}); err != nil {
+		t.Fatalf("ProcessJSONLines() error = %v", err)
+	}
+	want := "{\"id\":1,\"data\":{\"a\":[1,2]}}\n{\"id\":2,\"data\":{\"a\":[1,2]}}\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestProcessJSONLinesReportsLine(t *testing.T) {
+	var out bytes.Buffer
+	_, err := ProcessJSONLines(strings.NewReader("{}\n\n{\"a\":\n"), &out, nil, LinesOptions{})
+	if err == nil || !strings.HasPrefix(err.Error(), "line 3:") {
+		t.Errorf("ProcessJSONLines() error = %v, want it to name line 3", err)
+	}
+}
+
+func TestProcessJSONLinesFileInPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "events.jsonl")
+	if err := os.WriteFile(path, []byte("{\"id\":1}\n{\"id\":2}\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	assignments := []parser.Assignment{{Path: "ok", Operator: parser.OpAssignJSON, Value: "true"}}
+
+	if _, err := ProcessJSONLinesFile(path, "", assignments, LinesOptions{}); err != nil {
+		t.Fatalf("ProcessJSONLinesFile() error = %v", err)
+	}
+	wantContents(t, path, "{\"id\":1,\"ok\":true}\n{\"id\":2,\"ok\":true}\n")
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+
+	// A failing record leaves the file as it was
+	if err := os.WriteFile(path, []byte("{\"id\":1}\nnot json\n"), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ProcessJSONLinesFile(path, "", assignments, LinesOptions{}); err == nil {
+		t.Fatal("ProcessJSONLinesFile() succeeded with an invalid record")
+	}
+	wantContents(t, path, "{\"id\":1}\nnot json\n")
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
+
+func TestProcessJSONLinesFileKeepsSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "events.jsonl")
+	link := filepath.Join(dir, "link.jsonl")
+	if err := os.WriteFile(target, []byte("{\"id\":1}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("events.jsonl", link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	assignments := []parser.Assignment{{Path: "ok", Operator: parser.OpAssignJSON, Value: "true"}}
+
+	if _, err := ProcessJSONLinesFile(link, "", assignments, LinesOptions{}); err != nil {
+		t.Fatalf("ProcessJSONLinesFile() error = %v", err)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink was replaced by a regular file")
+	}
+	wantContents(t, target, "{\"id\":1,\"ok\":true}\n")
+}
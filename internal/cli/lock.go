@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+)
+
+// lockPollInterval is how often a contended lock is retried.
+const lockPollInterval = 50 * time.Millisecond
+
+// LockTimeoutError reports a lock that could not be acquired in time.
+type LockTimeoutError struct {
+	Path    string
+	Timeout time.Duration
+}
+
+func (e *LockTimeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s waiting for lock on %s", e.Timeout, e.Path)
+}
+
+// LockFile takes an advisory lock on filename for a read-modify-write. The
+// lock is held on a sidecar file (filename + ".lock") rather than the file
+// itself, because atomic writes replace the file and any lock on it. Other
+// je processes wait for the lock; programs that do not use it are not
+// blocked. A timeout of zero or less waits indefinitely. The returned
+// function releases the lock.
+func LockFile(filename string, timeout time.Duration) (func() error, error) {
+	lockPath := filename + ".lock"
+	deadline := time.Now().Add(timeout)
+
+	for {
+		unlock, ok, err := tryLock(lockPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock %s: %w", filename, err)
+		}
+		if ok {
+			return unlock, nil
+		}
+		if timeout > 0 && time.Now().After(deadline) {
+			return nil, &LockTimeoutError{Path: filename, Timeout: timeout}
+		}
+		time.Sleep(lockPollInterval)
+	}
+}
@@ -0,0 +1,20 @@
+//go:build !unix
+
+package cli
+
+import "os"
+
+// tryLock creates lockPath exclusively; its existence is the lock. A lock
+// file left behind by a crashed process must be removed by hand.
+func tryLock(lockPath string) (func() error, bool, error) {
+	f, err := os.OpenFile(lockPath, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	f.Close()
+
+	return func() error { return os.Remove(lockPath) }, true, nil
+}
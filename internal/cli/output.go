@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// placeholder matches a {name} placeholder in an output path template.
+var placeholder = regexp.MustCompile(`\{([a-z]*)\}`)
+
+// IsOutputTemplate reports whether an output path contains placeholders.
+func IsOutputTemplate(output string) bool {
+	return placeholder.MatchString(output)
+}
+
+// OutputPath expands an output path template for an input file:
+//
+//	{dir}   directory of the input file, as given
+//	{name}  file name, e.g. app.json
+//	{stem}  file name without its extension, e.g. app
+//	{ext}   extension including the dot, e.g. .json
+//
+// so "dist/{dir}/{stem}.prod{ext}" writes conf/app.json to
+// dist/conf/app.prod.json. Outputs without placeholders are returned as is.
+func OutputPath(template, filename string) (string, error) {
+	if !IsOutputTemplate(template) {
+		return template, nil
+	}
+	if filename == "-" {
+		return "", errors.New("output templates cannot be used with stdin")
+	}
+
+	name := filepath.Base(filename)
+	ext := filepath.Ext(name)
+	values := map[string]string{
+		"dir":  filepath.Dir(filename),
+		"name": name,
+		"stem": strings.TrimSuffix(name, ext),
+		"ext":  ext,
+	}
+
+	var err error
+	output := placeholder.ReplaceAllStringFunc(template, func(m string) string {
+		value, ok := values[m[1:len(m)-1]]
+		if !ok && err == nil {
+			err = fmt.Errorf("unknown placeholder %s in output %q", m, template)
+		}
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return filepath.Clean(output), nil
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOutputPath(t *testing.T) {
+	tests := []struct {
+		template, filename string
+		want               string
+	}{
+		{"dist/{dir}/{stem}.prod{ext}", "conf/app.json", "dist/conf/app.prod.json"},
+		{"{dir}/{name}", "conf/app.json", "conf/app.json"},
+		{"{dir}/{name}", "app.json", "app.json"},
+		{"out/{stem}", "conf/app.tar.gz", "out/app.tar"},
+		{"{name}{ext}", "Makefile", "Makefile"},
+		{"out.json", "conf/app.json", "out.json"},
+		{"-", "conf/app.json", "-"},
+	}
+	for _, tt := range tests {
+		got, err := OutputPath(tt.template, tt.filename)
+		if err != nil {
+			t.Errorf("OutputPath(%q, %q) error = %v", tt.template, tt.filename, err)
+			continue
+		}
+		if got != filepath.FromSlash(tt.want) {
+			t.Errorf("OutputPath(%q, %q) = %q, want %q", tt.template, tt.filename, got, tt.want)
+		}
+	}
+}
+
+func TestOutputPathErrors(t *testing.T) {
+	if _, err := OutputPath("out/{base}.json", "app.json"); err == nil {
+		t.Error("OutputPath() with an unknown placeholder succeeded")
+	}
+	if _, err := OutputPath("out/{name}", "-"); err == nil {
+		t.Error("OutputPath() with stdin succeeded")
+	}
+}
+
+func TestEditFileTemplateNamingInput(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+	if err := os.WriteFile(path, []byte(`{"port":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A template expanding to the input is an in-place edit, so the backup
+	// is kept
+	if _, err := EditFile(path, "{dir}/{name}", setPort, ProcessOptions{Backup: "~"}); err != nil {
+		t.Fatalf("EditFile() error = %v", err)
+	}
+	wantContents(t, path, `{"port":80}`)
+	wantContents(t, path+"~", `{"port":1}`)
+}
@@ -1,8 +1,14 @@
 package cli
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/vampire/je/internal/history"
 	"github.com/vampire/je/internal/json"
 	"github.com/vampire/je/internal/operations"
 	"github.com/vampire/je/internal/parser"
@@ -13,49 +19,277 @@ type ProcessResult struct {
 	Original []byte
 	Modified []byte
 	Filename string
+
+	// Source holds the file as read, before conversion to JSON, or nil if
+	// it did not exist. It is the template when writing the result back.
+	Source []byte
+
+	// Conflicts lists assignments whose effect depends on their order.
+	// They are warnings unless ProcessOptions.Strict is set.
+	Conflicts []parser.Conflict
+}
+
+// DefaultBackupSuffix is the backup suffix used when --backup is given without one.
+const DefaultBackupSuffix = "~"
+
+// ProcessOptions controls how a file is read and edited.
+type ProcessOptions struct {
+	CreateIfMissing bool
+	Format          json.FileFormat    // Overrides format detection from the file extension
+	Operations      operations.Options // Controls how assignments are applied
+	Strict          bool               // Fails on conflicting assignments instead of reporting them
+
+	// Lock holds an advisory lock on the file for the whole read-modify-write
+	// in EditFile, waiting up to LockTimeout (indefinitely if zero).
+	Lock        bool
+	LockTimeout time.Duration
+
+	// Retries is how many times EditFile re-reads and re-applies the
+	// assignments when the file changes between reading and writing it.
+	// With no retries such a change is reported as a *json.ModifiedError.
+	Retries int
+
+	// Backup, when set, is a suffix: EditFile keeps the file's previous
+	// contents at filename+Backup before replacing it.
+	Backup string
+
+	// Xattrs copies extended attributes from the file being replaced to
+	// its new contents. It is only supported on Linux.
+	Xattrs bool
+
+	// Journal records each in-place edit in the file's history so it can
+	// be listed and undone. It is off by default. See the history package.
+	Journal bool
 }
 
 // ProcessJSONFile applies assignments to a JSON file and returns the result.
 func ProcessJSONFile(filename string, assignments []parser.Assignment, createIfMissing bool) (*ProcessResult, error) {
-	// Read JSON file
-	data, err := ReadJSONFile(filename, createIfMissing)
+	return ProcessFile(filename, assignments, ProcessOptions{CreateIfMissing: createIfMissing})
+}
+
+// ProcessFile applies assignments to a JSON, YAML or TOML file and returns the result.
+// The result always holds JSON; WriteResultAs converts it back to the file's format.
+func ProcessFile(filename string, assignments []parser.Assignment, opts ProcessOptions) (*ProcessResult, error) {
+	source, err := readSource(filename, opts.CreateIfMissing)
+	if err != nil {
+		return nil, err
+	}
+	return processSource(filename, source, assignments, opts)
+}
+
+// processSource is ProcessFile for contents already read from filename; a
+// nil source stands for a file that does not exist yet.
+func processSource(filename string, source []byte, assignments []parser.Assignment, opts ProcessOptions) (*ProcessResult, error) {
+	conflicts := parser.DetectConflicts(assignments)
+	if opts.Strict && len(conflicts) > 0 {
+		return nil, &parser.ConflictError{Conflicts: conflicts}
+	}
+
+	// Read file as JSON
+	data, err := decodeSource(filename, source, opts.Format)
 	if err != nil {
 		return nil, err
 	}
 
 	// Validate JSON
-	if err := json.Validate(data); err != nil {
+	if err := json.ValidateFile(data, sourceName(filename)); err != nil {
 		return nil, fmt.Errorf("invalid JSON: %w", err)
 	}
 
-	// Apply assignments
-	result, err := operations.ApplyAssignments(data, assignments)
+	// Apply assignments, reporting every failing one if any fail
+	result, err := operations.ApplyAssignmentsWithOptions(data, assignments, opts.Operations)
 	if err != nil {
+		if verr := operations.ValidateAssignments(data, assignments, opts.Operations); verr != nil {
+			return nil, verr
+		}
 		return nil, err
 	}
 
 	return &ProcessResult{
-		Original: data,
-		Modified: result,
-		Filename: filename,
+		Original:  data,
+		Modified:  result,
+		Filename:  filename,
+		Source:    source,
+		Conflicts: conflicts,
 	}, nil
 }
 
+// EditFile applies assignments to a file and writes the result to outputFile,
+// or back to the file when outputFile is empty. In-place edits never clobber
+// a concurrent change: the write is abandoned, or retried per opts.Retries,
+// if the file no longer matches what was read. Existing files the
+// assignments leave unchanged are not rewritten.
+func EditFile(filename, outputFile string, assignments []parser.Assignment, opts ProcessOptions) (*ProcessResult, error) {
+	if filename != "-" && outputFile != "" {
+		// An output that names the input, e.g. "{dir}/{name}", is an
+		// in-place edit and gets its locking, backup and journal
+		output, err := OutputPath(outputFile, filename)
+		if err != nil {
+			return nil, err
+		}
+		if filepath.Clean(output) == filepath.Clean(filename) {
+			outputFile = ""
+		}
+	}
+	if filename == "-" || outputFile != "" {
+		result, err := ProcessFile(filename, assignments, opts)
+		if err != nil {
+			return nil, err
+		}
+		return result, WriteResultAs(result, outputFile, opts)
+	}
+
+	if opts.Lock {
+		unlock, err := LockFile(filename, opts.LockTimeout)
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+
+	for attempt := 0; ; attempt++ {
+		// The snapshot, backup and edit all come from a single read
+		source, snapshot, err := readForEdit(filename, opts)
+		if err != nil {
+			return nil, err
+		}
+		result, err := processSource(filename, source, assignments, opts)
+		if err != nil {
+			return nil, err
+		}
+		// Leave existing files untouched when nothing changed
+		if source != nil && bytes.Equal(result.Original, result.Modified) {
+			return result, nil
+		}
+
+		data, err := json.Encode(result.Modified, source, json.ResolveFormat(filename, opts.Format))
+		if err != nil {
+			return nil, fmt.Errorf("failed to write output: %w", err)
+		}
+		perm := GetFilePermissions(filename)
+		staged, err := json.StageFile(filename, data, perm, json.WriteOptions{Xattrs: opts.Xattrs, Expect: snapshot})
+		if err != nil {
+			return nil, fmt.Errorf("failed to write output: %w", err)
+		}
+
+		// Only back up a file that is about to be replaced
+		err = snapshot.Verify(filename)
+		if err == nil && opts.Backup != "" && source != nil {
+			if err := json.AtomicWriteFile(filename+opts.Backup, source, perm, json.WriteOptions{}); err != nil {
+				staged.Abort()
+				return nil, fmt.Errorf("failed to write backup: %w", err)
+			}
+		}
+		if err == nil {
+			err = commitStaged(staged)
+		}
+		if err != nil {
+			staged.Abort()
+		}
+
+		var modified *json.ModifiedError
+		if errors.As(err, &modified) && attempt < opts.Retries {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to write output: %w", err)
+		}
+		if opts.Journal {
+			if err := history.Record(filename, source, assignments); err != nil {
+				return result, err
+			}
+		}
+		return result, nil
+	}
+}
+
+// readForEdit reads a file to be edited in place along with a snapshot of
+// what was read. A missing file yields nil contents if opts.CreateIfMissing
+// is set.
+func readForEdit(filename string, opts ProcessOptions) ([]byte, *json.Snapshot, error) {
+	source, snapshot, err := json.ReadSnapshot(filename)
+	if os.IsNotExist(err) && opts.CreateIfMissing {
+		return nil, &json.Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return source, snapshot, nil
+}
+
+// readOriginal returns the raw contents of a file, or nil if it does not exist.
+func readOriginal(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
 // WriteResult writes the result to the appropriate destination.
 func WriteResult(result []byte, filename, outputFile string) error {
+	var source []byte
+	if filename != "-" {
+		source, _ = readOriginal(filename)
+	}
+	return WriteResultAs(&ProcessResult{Modified: result, Filename: filename, Source: source}, outputFile, ProcessOptions{})
+}
+
+// WriteResultAs writes the result to the appropriate destination in the format
+// given by opts.Format, copying extended attributes if opts.Xattrs is set.
+// outputFile may be a template expanded per input file (see OutputPath); missing
+// directories are created. Without an explicit format the output keeps the
+// input's format unless outputFile's extension names another one, and the
+// input is the template for its comments and layout.
+func WriteResultAs(result *ProcessResult, outputFile string, opts ProcessOptions) error {
+	filename, format := result.Filename, opts.Format
+
 	// Determine output destination
 	output := filename
 	if outputFile != "" {
-		output = outputFile
+		var err error
+		if output, err = OutputPath(outputFile, filename); err != nil {
+			return err
+		}
+		if output != "-" {
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return fmt.Errorf("failed to write output: %w", err)
+			}
+		}
+	}
+
+	// Convert to the output format, keeping the input's layout when the
+	// formats match and otherwise that of the file being replaced
+	outFormat := json.OutputFormat(filename, output, format)
+	template := result.Source
+	if json.ResolveFormat(filename, format) != outFormat {
+		template = nil
+		if output != "-" {
+			template, _ = readOriginal(output)
+		}
+	}
+	data, err := json.Encode(result.Modified, template, outFormat)
+	if err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+
+	if output == "-" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("failed to write output: %w", err)
+		}
+		return nil
 	}
 
 	// Get original file permissions
 	perm := GetFilePermissions(filename)
 
 	// Write result
-	if err := json.WriteFile(output, result, perm); err != nil {
+	if err := json.AtomicWriteFile(output, data, perm, json.WriteOptions{Xattrs: opts.Xattrs}); err != nil {
 		return fmt.Errorf("failed to write output: %w", err)
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -0,0 +1,40 @@
+//go:build linux
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestEditFileCopiesXattrs(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+	out := filepath.Join(dir, "out.json")
+	for _, file := range []string{path, out} {
+		if err := os.WriteFile(file, []byte(`{"port":1}`), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := syscall.Setxattr(file, "user.je.test", []byte("kept"), 0); err != nil {
+			t.Skipf("extended attributes not supported: %v", err)
+		}
+	}
+
+	// In place and to a separate output, each keeps the attributes of the
+	// file it replaces
+	for _, output := range []string{"", out} {
+		if _, err := EditFile(path, output, setPort, ProcessOptions{Xattrs: true}); err != nil {
+			t.Fatalf("EditFile() error = %v", err)
+		}
+	}
+	for _, file := range []string{path, out} {
+		wantContents(t, file, `{"port":80}`)
+		value := make([]byte, 16)
+		n, err := syscall.Getxattr(file, "user.je.test", value)
+		if err != nil || string(value[:n]) != "kept" {
+			t.Errorf("%s: user.je.test = %q, %v, want kept", filepath.Base(file), value[:n], err)
+		}
+	}
+}
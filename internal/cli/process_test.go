@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/vampire/je/internal/json"
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestEditFileKeepsSourceLayout(t *testing.T) {
+	dir := t.TempDir()
+	in := filepath.Join(dir, "in.yaml")
+	original := "# service config\nname: api # the service name\nport: 8080\n"
+	if err := os.WriteFile(in, []byte(original), 0644); err != nil {
+		t.Fatal(err)
+	}
+	assignments := []parser.Assignment{{Path: "port", Operator: parser.OpAssignJSON, Value: "9090"}}
+
+	tests := []struct {
+		output string
+		want   string
+	}{
+		{"out.yaml", "# service config\nname: api # the service name\nport: 9090\n"},
+		{"out", "# service config\nname: api # the service name\nport: 9090\n"},
+		{"out.json", `{"name":"api","port":9090}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.output, func(t *testing.T) {
+			out := filepath.Join(dir, tt.output)
+			if _, err := EditFile(in, out, assignments, ProcessOptions{}); err != nil {
+				t.Fatalf("EditFile() error = %v", err)
+			}
+			got, err := os.ReadFile(out)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("output =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	if got, _ := os.ReadFile(in); string(got) != original {
+		t.Errorf("input was modified:\n%s", got)
+	}
+}
+
+func TestEditFileBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+	if err := os.WriteFile(path, []byte(`{"port":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := EditFile(path, "", setPort, ProcessOptions{Backup: ".bak"}); err != nil {
+		t.Fatalf("EditFile() error = %v", err)
+	}
+	wantContents(t, path, `{"port":80}`)
+	wantContents(t, path+".bak", `{"port":1}`)
+
+	// An edit that changes nothing leaves the file and its backup alone
+	if err := os.Remove(path + ".bak"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EditFile(path, "", setPort, ProcessOptions{Backup: ".bak"}); err != nil {
+		t.Fatalf("EditFile() error = %v", err)
+	}
+	wantContents(t, path+".bak", "")
+
+	// A missing file is created only with CreateIfMissing, and has no backup
+	created := filepath.Join(dir, "new.json")
+	if _, err := EditFile(created, "", setPort, ProcessOptions{Backup: ".bak"}); err == nil {
+		t.Error("EditFile() of a missing file succeeded without CreateIfMissing")
+	}
+	if _, err := EditFile(created, "", setPort, ProcessOptions{Backup: ".bak", CreateIfMissing: true}); err != nil {
+		t.Fatalf("EditFile() error = %v", err)
+	}
+	wantContents(t, created, `{"port":80}`)
+	wantContents(t, created+".bak", "")
+}
+
+func TestEditFileDetectsConcurrentChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.json")
+
+	// Another writer changes the file after it was read, on the first
+	// attempt only
+	defer func(commit func(*json.StagedFile) error) { commitStaged = commit }(commitStaged)
+	var attempts int
+	commitStaged = func(s *json.StagedFile) error {
+		attempts++
+		if attempts == 1 {
+			if err := os.WriteFile(path, []byte(`{"port":1,"host":"db"}`), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return s.Commit()
+	}
+
+	if err := os.WriteFile(path, []byte(`{"port":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var modified *json.ModifiedError
+	if _, err := EditFile(path, "", setPort, ProcessOptions{Backup: "~"}); !errors.As(err, &modified) {
+		t.Fatalf("EditFile() error = %v, want *json.ModifiedError", err)
+	}
+	wantContents(t, path, `{"port":1,"host":"db"}`)
+
+	// With a retry the edit is applied again on top of the other change
+	attempts = 0
+	if err := os.WriteFile(path, []byte(`{"port":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := EditFile(path, "", setPort, ProcessOptions{Retries: 1}); err != nil {
+		t.Fatalf("EditFile() with a retry error = %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("commit attempts = %d, want 2", attempts)
+	}
+	wantContents(t, path, `{"port":80,"host":"db"}`)
+}
+
+func TestEditFileLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	if err := os.WriteFile(path, []byte(`{"port":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	release, err := LockFile(path, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var timeout *LockTimeoutError
+	if _, err := EditFile(path, "", setPort, ProcessOptions{Lock: true, LockTimeout: 50 * time.Millisecond}); !errors.As(err, &timeout) {
+		t.Errorf("EditFile() while locked error = %v, want *LockTimeoutError", err)
+	}
+	wantContents(t, path, `{"port":1}`)
+	release()
+
+	if _, err := EditFile(path, "", setPort, ProcessOptions{Lock: true, LockTimeout: time.Second}); err != nil {
+		t.Fatalf("EditFile() error = %v", err)
+	}
+	wantContents(t, path, `{"port":80}`)
+
+	// The lock is released once the edit is done
+	release, err = LockFile(path, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("LockFile() after EditFile() error = %v", err)
+	}
+	release()
+}
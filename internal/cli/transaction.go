@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/vampire/je/internal/history"
+	"github.com/vampire/je/internal/json"
+	"github.com/vampire/je/internal/parser"
+)
+
+// RollbackError reports files that could not be restored after a failed
+// transaction, leaving them edited.
+type RollbackError struct {
+	Err    error   // The failure that triggered the rollback
+	Failed []error // One error per file that could not be restored
+}
+
+func (e *RollbackError) Error() string {
+	return fmt.Sprintf("%v; rollback failed: %v", e.Err, errors.Join(e.Failed...))
+}
+
+func (e *RollbackError) Unwrap() error {
+	return e.Err
+}
+
+// commitStaged renames a staged file into place. Tests replace it to make a
+// commit fail or to change the file just before it is replaced.
+var commitStaged = (*json.StagedFile).Commit
+
+// pendingEdit is a file staged as part of a transaction.
+type pendingEdit struct {
+	index    int
+	original []byte         // Contents before the edit; nil if the file did not exist
+	snapshot *json.Snapshot // What original was read from
+	perm     os.FileMode
+	staged   *json.StagedFile
+}
+
+// ProcessAtomic edits every file in place as a single transaction: all files
+// are processed and staged to temp files first, and only if every one
+// succeeds are they renamed into place. If a rename fails, files already
+// replaced are restored from their original contents. Either way no file is
+// left half-edited, and the returned summary reports the file that failed
+// with the rest skipped. A file may only be listed once.
+func ProcessAtomic(files []string, assignments []parser.Assignment, opts ProcessOptions) (*EachSummary, error) {
+	summary := &EachSummary{Files: make([]FileResult, len(files))}
+	for i, file := range files {
+		summary.Files[i] = FileResult{File: file, Status: StatusSkipped}
+	}
+	fail := func(i int, err error) (*EachSummary, error) {
+		summary.Files[i] = FileResult{File: files[i], Status: StatusFailed, Err: err}
+		for j := range summary.Files {
+			if j != i {
+				summary.Files[j].Status = StatusSkipped
+			}
+		}
+		return summary, err
+	}
+
+	// A file listed twice would be staged twice from the same contents and
+	// the second commit would see the first as a concurrent change
+	seen := make(map[string]int)
+	for i, file := range files {
+		if file == "-" {
+			continue
+		}
+		key := filepath.Clean(file)
+		if abs, err := filepath.Abs(file); err == nil {
+			key = abs
+		}
+		if j, ok := seen[key]; ok {
+			return fail(i, fmt.Errorf("%s is listed more than once (also as %s)", file, files[j]))
+		}
+		seen[key] = i
+	}
+
+	if opts.Lock {
+		unlock, err := lockAll(files, opts)
+		if err != nil {
+			return summary, err
+		}
+		defer unlock()
+	}
+
+	// Stage every file before touching any of them
+	var pending []*pendingEdit
+	defer func() {
+		for _, p := range pending {
+			p.staged.Abort()
+		}
+	}()
+	for i, file := range files {
+		if file == "-" {
+			return fail(i, errors.New("stdin cannot be part of an atomic edit"))
+		}
+		p, result, err := stageEdit(i, file, assignments, opts)
+		if err != nil {
+			return fail(i, err)
+		}
+		summary.Files[i].Conflicts = result.Conflicts
+		if p == nil {
+			summary.Files[i].Status = StatusUnchanged
+			continue
+		}
+		summary.Files[i].Status = StatusChanged
+		pending = append(pending, p)
+	}
+
+	for n, p := range pending {
+		if err := commitEdit(files[p.index], p, opts.Backup); err != nil {
+			// A failed directory sync still leaves the file replaced
+			committed := pending[:n]
+			if p.staged.Committed() {
+				committed = pending[:n+1]
+			}
+			if rollbackErr := rollback(files, committed); rollbackErr != nil {
+				err = &RollbackError{Err: err, Failed: rollbackErr}
+			}
+			return fail(p.index, err)
+		}
+	}
+
+	if opts.Journal {
+		for _, p := range pending {
+			if err := history.Record(files[p.index], p.original, assignments); err != nil {
+				return summary, err
+			}
+		}
+	}
+	pending = nil
+	return summary, nil
+}
+
+// stageEdit processes one file and stages its new contents. Unchanged
+// existing files are not staged and yield a nil pendingEdit.
+func stageEdit(index int, file string, assignments []parser.Assignment, opts ProcessOptions) (*pendingEdit, *ProcessResult, error) {
+	original, snapshot, err := readForEdit(file, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := processSource(file, original, assignments, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if original != nil && bytes.Equal(result.Original, result.Modified) {
+		return nil, result, nil
+	}
+
+	perm := GetFilePermissions(file)
+	data, err := json.Encode(result.Modified, result.Source, json.ResolveFormat(file, opts.Format))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write output: %w", err)
+	}
+	staged, err := json.StageFile(file, data, perm, json.WriteOptions{Xattrs: opts.Xattrs, Expect: snapshot})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to write output: %w", err)
+	}
+	return &pendingEdit{index: index, original: original, snapshot: snapshot, perm: perm, staged: staged}, result, nil
+}
+
+// commitEdit renames a staged file into place once it has checked that the
+// file is unchanged since it was read, backing up the original contents
+// first if backup is set, as EditFile does.
+func commitEdit(file string, p *pendingEdit, backup string) error {
+	if err := p.snapshot.Verify(file); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	if backup != "" && p.original != nil {
+		if err := json.AtomicWriteFile(file+backup, p.original, p.perm, json.WriteOptions{}); err != nil {
+			return fmt.Errorf("failed to write backup: %w", err)
+		}
+	}
+	if err := commitStaged(p.staged); err != nil {
+		return fmt.Errorf("failed to write output: %w", err)
+	}
+	return nil
+}
+
+// rollback restores committed files to their original contents, removing
+// those the transaction created.
+func rollback(files []string, committed []*pendingEdit) []error {
+	var failed []error
+	for _, p := range committed {
+		var err error
+		if p.original == nil {
+			err = os.Remove(p.staged.Path)
+		} else {
+			err = json.AtomicWriteFile(p.staged.Path, p.original, p.perm, json.WriteOptions{})
+		}
+		if err != nil {
+			failed = append(failed, fmt.Errorf("%s: %w", files[p.index], err))
+		}
+	}
+	return failed
+}
+
+// lockAll locks every file, in sorted order so that concurrent transactions
+// over overlapping files cannot deadlock.
+func lockAll(files []string, opts ProcessOptions) (func(), error) {
+	sorted := append([]string(nil), files...)
+	sort.Strings(sorted)
+
+	var unlocks []func() error
+	unlock := func() {
+		for _, u := range unlocks {
+			u()
+		}
+	}
+	for i, file := range sorted {
+		if i > 0 && file == sorted[i-1] {
+			continue
+		}
+		u, err := LockFile(file, opts.LockTimeout)
+		if err != nil {
+			unlock()
+			return nil, err
+		}
+		unlocks = append(unlocks, u)
+	}
+	return unlock, nil
+}
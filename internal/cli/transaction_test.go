@@ -0,0 +1,236 @@
+package cli
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vampire/je/internal/json"
+	"github.com/vampire/je/internal/parser"
+)
+
+var setPort = []parser.Assignment{{Path: "port", Operator: parser.OpAssignJSON, Value: "80"}}
+
+// writeFiles creates each named file in dir with the given contents and
+// returns their paths in order. Files with empty contents are not created.
+func writeFiles(t *testing.T, dir string, names []string, contents []string) []string {
+	t.Helper()
+	paths := make([]string, len(names))
+	for i, name := range names {
+		paths[i] = filepath.Join(dir, name)
+		if contents[i] == "" {
+			continue
+		}
+		if err := os.WriteFile(paths[i], []byte(contents[i]), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return paths
+}
+
+func wantContents(t *testing.T, path, want string) {
+	t.Helper()
+	got, err := os.ReadFile(path)
+	if want == "" {
+		if !os.IsNotExist(err) {
+			t.Errorf("%s exists with %q, want it absent", filepath.Base(path), got)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Errorf("%s = %s, want %s", filepath.Base(path), got, want)
+	}
+}
+
+func wantStatuses(t *testing.T, summary *EachSummary, want ...FileStatus) {
+	t.Helper()
+	for i, f := range summary.Files {
+		if f.Status != want[i] {
+			t.Errorf("%s status = %v, want %v", filepath.Base(f.File), f.Status, want[i])
+		}
+	}
+}
+
+func TestProcessAtomic(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "b.json", "c.json"}, []string{`{"port":1}`, `{"port":80}`, ""})
+
+	summary, err := ProcessAtomic(files, setPort, ProcessOptions{CreateIfMissing: true, Backup: "~"})
+	if err != nil {
+		t.Fatalf("ProcessAtomic() error = %v", err)
+	}
+	wantStatuses(t, summary, StatusChanged, StatusUnchanged, StatusChanged)
+	wantContents(t, files[0], `{"port":80}`)
+	wantContents(t, files[1], `{"port":80}`)
+	wantContents(t, files[2], `{"port":80}`)
+	wantContents(t, files[0]+"~", `{"port":1}`)
+	wantContents(t, files[1]+"~", "")
+}
+
+func TestProcessAtomicReportsConflicts(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "b.json"}, []string{`{"port":1}`, `{"port":80}`})
+	assignments, err := parser.ParseAssignments([]string{"port:=8080", "port:=80"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := ProcessAtomic(files, assignments, ProcessOptions{})
+	if err != nil {
+		t.Fatalf("ProcessAtomic() error = %v", err)
+	}
+	wantStatuses(t, summary, StatusChanged, StatusUnchanged)
+	for _, f := range summary.Files {
+		if len(f.Conflicts) != 1 || f.Conflicts[0].Kind != parser.ConflictOverwrite {
+			t.Errorf("%s conflicts = %v, want one overwrite", filepath.Base(f.File), f.Conflicts)
+		}
+	}
+}
+
+func TestProcessAtomicProcessingFailure(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "b.json", "c.json"}, []string{`{"port":1}`, `{"port":`, `{"port":3}`})
+
+	summary, err := ProcessAtomic(files, setPort, ProcessOptions{})
+	if err == nil {
+		t.Fatal("ProcessAtomic() succeeded with invalid JSON")
+	}
+	wantStatuses(t, summary, StatusSkipped, StatusFailed, StatusSkipped)
+	wantContents(t, files[0], `{"port":1}`)
+	wantContents(t, files[2], `{"port":3}`)
+	if entries, _ := os.ReadDir(dir); len(entries) != 3 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
+
+func TestProcessAtomicRollsBackOnCommitFailure(t *testing.T) {
+	// Whether or not the failing file was already renamed into place, the
+	// files before it and the failing file itself must end up as they were
+	for _, renamed := range []bool{false, true} {
+		t.Run(map[bool]string{false: "before rename", true: "after rename"}[renamed], func(t *testing.T) {
+			dir := t.TempDir()
+			files := writeFiles(t, dir, []string{"a.json", "b.json", "c.json"}, []string{`{"port":1}`, "", `{"port":3}`})
+
+			failure := errors.New("disk full")
+			defer func(commit func(*json.StagedFile) error) { commitStaged = commit }(commitStaged)
+			commitStaged = func(s *json.StagedFile) error {
+				if filepath.Base(s.Path) != "c.json" {
+					return s.Commit()
+				}
+				if renamed {
+					if err := s.Commit(); err != nil {
+						t.Fatal(err)
+					}
+				}
+				return failure
+			}
+
+			summary, err := ProcessAtomic(files, setPort, ProcessOptions{CreateIfMissing: true})
+			if !errors.Is(err, failure) {
+				t.Fatalf("ProcessAtomic() error = %v, want %v", err, failure)
+			}
+			var rollbackErr *RollbackError
+			if errors.As(err, &rollbackErr) {
+				t.Fatalf("rollback failed: %v", err)
+			}
+			wantStatuses(t, summary, StatusSkipped, StatusSkipped, StatusFailed)
+			wantContents(t, files[0], `{"port":1}`)
+			wantContents(t, files[1], "")
+			wantContents(t, files[2], `{"port":3}`)
+		})
+	}
+}
+
+func TestProcessAtomicBacksUpBeforeCommit(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "b.json"}, []string{`{"port":1}`, `{"port":2}`})
+
+	defer func(commit func(*json.StagedFile) error) { commitStaged = commit }(commitStaged)
+	commitStaged = func(s *json.StagedFile) error {
+		if _, err := os.Stat(s.Path + "~"); err != nil {
+			t.Errorf("%s committed before its backup was written: %v", filepath.Base(s.Path), err)
+		}
+		return s.Commit()
+	}
+
+	if _, err := ProcessAtomic(files, setPort, ProcessOptions{Backup: "~"}); err != nil {
+		t.Fatalf("ProcessAtomic() error = %v", err)
+	}
+	wantContents(t, files[0]+"~", `{"port":1}`)
+	wantContents(t, files[1]+"~", `{"port":2}`)
+}
+
+func TestProcessAtomicBackupFailure(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "b.json"}, []string{`{"port":1}`, `{"port":2}`})
+	// A directory in the way of b.json's backup makes writing it fail
+	if err := os.Mkdir(files[1]+"~", 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	summary, err := ProcessAtomic(files, setPort, ProcessOptions{Backup: "~"})
+	if err == nil {
+		t.Fatal("ProcessAtomic() succeeded without a backup")
+	}
+	wantStatuses(t, summary, StatusSkipped, StatusFailed)
+	wantContents(t, files[0], `{"port":1}`)
+	wantContents(t, files[1], `{"port":2}`)
+}
+
+func TestProcessAtomicRejectsDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "b.json"}, []string{`{"port":1}`, `{"port":2}`})
+	files = append(files, filepath.Join(dir, ".", "a.json"))
+
+	summary, err := ProcessAtomic(files, setPort, ProcessOptions{})
+	if err == nil || !strings.Contains(err.Error(), "listed more than once") {
+		t.Fatalf("ProcessAtomic() error = %v, want a duplicate file error", err)
+	}
+	wantStatuses(t, summary, StatusSkipped, StatusSkipped, StatusFailed)
+	wantContents(t, files[0], `{"port":1}`)
+	wantContents(t, files[1], `{"port":2}`)
+}
+
+func TestRollbackReportsFailures(t *testing.T) {
+	dir := t.TempDir()
+	files := writeFiles(t, dir, []string{"a.json", "missing/b.json"}, []string{`{"port":80}`, ""})
+	committed := []*pendingEdit{
+		{index: 0, original: []byte(`{"port":1}`), perm: 0644, staged: &json.StagedFile{Path: files[0]}},
+		{index: 1, original: []byte(`{"port":2}`), perm: 0644, staged: &json.StagedFile{Path: files[1]}},
+	}
+
+	failed := rollback(files, committed)
+	if len(failed) != 1 {
+		t.Fatalf("rollback() = %v, want one failure", failed)
+	}
+	wantContents(t, files[0], `{"port":1}`)
+}
+
+func TestLockAll(t *testing.T) {
+	dir := t.TempDir()
+	a, b := filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")
+
+	// A file listed twice is locked once rather than deadlocking
+	unlock, err := lockAll([]string{b, a, b}, ProcessOptions{LockTimeout: time.Second})
+	if err != nil {
+		t.Fatalf("lockAll() error = %v", err)
+	}
+
+	var timeout *LockTimeoutError
+	if _, err := LockFile(a, 50*time.Millisecond); !errors.As(err, &timeout) {
+		t.Errorf("LockFile() while locked error = %v, want *LockTimeoutError", err)
+	}
+
+	unlock()
+	release, err := LockFile(a, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("LockFile() after unlock error = %v", err)
+	}
+	release()
+}
@@ -0,0 +1,56 @@
+package diff
+
+import (
+	"fmt"
+	"os"
+)
+
+// ColorMode selects when diff output is colored.
+type ColorMode int
+
+const (
+	ColorAuto   ColorMode = iota // Color only when writing to a terminal
+	ColorAlways                  // Always emit ANSI colors
+	ColorNever                   // Never emit ANSI colors
+)
+
+// ParseColorMode parses a --color flag value: auto, always or never.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch s {
+	case "", "auto":
+		return ColorAuto, nil
+	case "always":
+		return ColorAlways, nil
+	case "never":
+		return ColorNever, nil
+	default:
+		return ColorAuto, fmt.Errorf("invalid color mode %q: expected auto, always or never", s)
+	}
+}
+
+// Enabled reports whether output written to f should be colored.
+// In auto mode colors are disabled when NO_COLOR is set or f is not a terminal.
+func (m ColorMode) Enabled(f *os.File) bool {
+	switch m {
+	case ColorAlways:
+		return true
+	case ColorNever:
+		return false
+	}
+
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(f)
+}
+
+func isTerminal(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
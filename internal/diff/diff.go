@@ -2,65 +2,264 @@ package diff
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strings"
 
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
-// ShowDiff displays a colored line-by-line diff between two strings.
+const (
+	colorRed       = "\033[31m"
+	colorGreen     = "\033[32m"
+	colorCyan      = "\033[36m"
+	colorBold      = "\033[1m"
+	colorReverse   = "\033[7m"
+	colorNoReverse = "\033[27m"
+	colorReset     = "\033[0m"
+)
+
+// Options controls how a unified diff is rendered.
+type Options struct {
+	Context  int    // Number of unchanged lines shown around each change
+	Color    bool   // Emit ANSI colors
+	WordDiff bool   // Highlight changed words within modified lines (requires Color)
+	FromFile string // Name shown in the --- header
+	ToFile   string // Name shown in the +++ header
+}
+
+// DefaultOptions returns options matching `diff -u`: three lines of context and no color.
+func DefaultOptions() Options {
+	return Options{Context: 3}
+}
+
+// line is a single line of a diff with its position in the old and new text.
+type line struct {
+	kind  byte // ' ', '-' or '+'
+	text  string
+	oldNo int
+	newNo int
+}
+
+// ShowDiff displays a unified diff between two strings on stdout,
+// colored when stdout is a terminal.
 func ShowDiff(original, modified string) {
+	opts := DefaultOptions()
+	opts.Color = ColorAuto.Enabled(os.Stdout)
+	_ = WriteDiff(os.Stdout, original, modified, opts)
+}
+
+// WriteDiff writes a unified diff between two strings to w.
+// The output is accepted by patch and git apply when Color is disabled.
+func WriteDiff(w io.Writer, original, modified string, opts Options) error {
+	lines := diffLines(original, modified)
+	hunks := groupHunks(lines, opts.Context)
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	var b strings.Builder
+	writeHeader(&b, opts)
+	for _, hunk := range hunks {
+		writeHunk(&b, hunk, opts)
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// diffLines computes a line-level diff and numbers each line.
+func diffLines(original, modified string) []line {
 	dmp := diffmatchpatch.New()
 	charArray1, charArray2, lineArray := dmp.DiffLinesToChars(original, modified)
 	diffs := dmp.DiffMain(charArray1, charArray2, false)
 	diffs = dmp.DiffCharsToLines(diffs, lineArray)
 
-	for _, diff := range diffs {
-		lines := strings.Split(strings.TrimSuffix(diff.Text, "\n"), "\n")
-
-		switch diff.Type {
-		case diffmatchpatch.DiffDelete:
-			printLines(lines, "\033[31m-", "\033[0m")
-		case diffmatchpatch.DiffInsert:
-			printLines(lines, "\033[32m+", "\033[0m")
-		case diffmatchpatch.DiffEqual:
-			printContextLines(lines)
+	var lines []line
+	oldNo, newNo := 1, 1
+	for _, d := range diffs {
+		for _, text := range splitLines(d.Text) {
+			switch d.Type {
+			case diffmatchpatch.DiffDelete:
+				lines = append(lines, line{kind: '-', text: text, oldNo: oldNo, newNo: newNo})
+				oldNo++
+			case diffmatchpatch.DiffInsert:
+				lines = append(lines, line{kind: '+', text: text, oldNo: oldNo, newNo: newNo})
+				newNo++
+			case diffmatchpatch.DiffEqual:
+				lines = append(lines, line{kind: ' ', text: text, oldNo: oldNo, newNo: newNo})
+				oldNo++
+				newNo++
+			}
 		}
 	}
+	return lines
 }
 
-func printLines(lines []string, prefix, suffix string) {
-	for _, line := range lines {
-		if line != "" {
-			fmt.Printf("%s%s%s\n", prefix, line, suffix)
-		}
+// splitLines splits text into lines, keeping each line's trailing newline.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
 	}
+	return lines
 }
 
-func printContextLines(lines []string) {
-	if len(lines) <= 6 {
-		// Show all lines if there are 6 or fewer
-		for _, line := range lines {
-			if line != "" {
-				fmt.Printf(" %s\n", line)
-			}
+// groupHunks splits the diff into hunks, each holding its changes plus up to
+// context unchanged lines on either side. Changes closer than twice the
+// context share a hunk.
+func groupHunks(lines []line, context int) [][]line {
+	if context < 0 {
+		context = 0
+	}
+
+	var hunks [][]line
+	start, end := -1, -1
+	for i, l := range lines {
+		if l.kind == ' ' {
+			continue
 		}
+		lo := max(i-context, 0)
+		if start >= 0 && lo > end {
+			hunks = append(hunks, lines[start:end])
+			start = -1
+		}
+		if start < 0 {
+			start = lo
+		}
+		end = min(i+context+1, len(lines))
+	}
+	if start >= 0 {
+		hunks = append(hunks, lines[start:end])
+	}
+	return hunks
+}
+
+func writeHeader(b *strings.Builder, opts Options) {
+	if opts.FromFile == "" && opts.ToFile == "" {
 		return
 	}
+	writeColored(b, opts.Color, colorBold, "--- "+opts.FromFile+"\n")
+	writeColored(b, opts.Color, colorBold, "+++ "+opts.ToFile+"\n")
+}
 
-	// Show first 3 lines
-	for i := 0; i < 3 && i < len(lines); i++ {
-		if lines[i] != "" {
-			fmt.Printf(" %s\n", lines[i])
+func writeHunk(b *strings.Builder, hunk []line, opts Options) {
+	oldStart, newStart := hunk[0].oldNo, hunk[0].newNo
+	oldCount, newCount := 0, 0
+	for _, l := range hunk {
+		if l.kind != '+' {
+			oldCount++
+		}
+		if l.kind != '-' {
+			newCount++
 		}
 	}
+	// An empty range is addressed by the line before it.
+	if oldCount == 0 {
+		oldStart--
+	}
+	if newCount == 0 {
+		newStart--
+	}
+
+	header := fmt.Sprintf("@@ -%d,%d +%d,%d @@\n", oldStart, oldCount, newStart, newCount)
+	writeColored(b, opts.Color, colorCyan, header)
+
+	for i := 0; i < len(hunk); {
+		if hunk[i].kind == ' ' {
+			writeLine(b, hunk[i], hunk[i].text, opts)
+			i++
+			continue
+		}
+		i = writeChangeBlock(b, hunk, i, opts)
+	}
+}
+
+// writeChangeBlock writes a run of deleted lines followed by inserted lines
+// starting at i and returns the index after the block. When word diffs are
+// enabled and the deleted and inserted runs have the same length, lines are
+// paired and the changed words within each pair are highlighted.
+func writeChangeBlock(b *strings.Builder, hunk []line, i int, opts Options) int {
+	delStart := i
+	for i < len(hunk) && hunk[i].kind == '-' {
+		i++
+	}
+	insStart := i
+	for i < len(hunk) && hunk[i].kind == '+' {
+		i++
+	}
+	deleted, inserted := hunk[delStart:insStart], hunk[insStart:i]
+
+	if opts.WordDiff && opts.Color && len(deleted) == len(inserted) {
+		for j := range deleted {
+			oldText, newText := highlightWords(deleted[j].text, inserted[j].text)
+			deleted[j].text, inserted[j].text = oldText, newText
+		}
+	}
+
+	for _, l := range deleted {
+		writeLine(b, l, l.text, opts)
+	}
+	for _, l := range inserted {
+		writeLine(b, l, l.text, opts)
+	}
+	return i
+}
+
+func writeLine(b *strings.Builder, l line, text string, opts Options) {
+	noNewline := !strings.HasSuffix(text, "\n")
+	text = strings.TrimSuffix(text, "\n")
+
+	switch {
+	case !opts.Color || l.kind == ' ':
+		b.WriteByte(l.kind)
+		b.WriteString(text)
+	case l.kind == '-':
+		b.WriteString(colorRed + "-" + text + colorReset)
+	default:
+		b.WriteString(colorGreen + "+" + text + colorReset)
+	}
+	b.WriteByte('\n')
 
-	fmt.Println(" ...")
+	if noNewline {
+		b.WriteString("\\ No newline at end of file\n")
+	}
+}
+
+// highlightWords marks the segments that differ between two versions of a
+// line using reverse video.
+func highlightWords(oldText, newText string) (oldOut, newOut string) {
+	dmp := diffmatchpatch.New()
+	diffs := dmp.DiffMain(oldText, newText, false)
+	diffs = dmp.DiffCleanupSemantic(diffs)
 
-	// Show last 3 lines
-	for i := len(lines) - 3; i < len(lines); i++ {
-		if i >= 0 && lines[i] != "" {
-			fmt.Printf(" %s\n", lines[i])
+	var oldB, newB strings.Builder
+	for _, d := range diffs {
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			oldB.WriteString(d.Text)
+			newB.WriteString(d.Text)
+		case diffmatchpatch.DiffDelete:
+			oldB.WriteString(highlight(d.Text))
+		case diffmatchpatch.DiffInsert:
+			newB.WriteString(highlight(d.Text))
 		}
 	}
+	return oldB.String(), newB.String()
+}
+
+// highlight wraps text in reverse video, leaving any trailing newline outside
+// so line handling is unaffected.
+func highlight(text string) string {
+	trimmed := strings.TrimSuffix(text, "\n")
+	return colorReverse + trimmed + colorNoReverse + text[len(trimmed):]
 }
 
+func writeColored(b *strings.Builder, color bool, code, text string) {
+	if !color {
+		b.WriteString(text)
+		return
+	}
+	trimmed := strings.TrimSuffix(text, "\n")
+	b.WriteString(code + trimmed + colorReset + text[len(trimmed):])
+}
@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriteDiff(t *testing.T) {
+	original := "{\n  \"a\": 1,\n  \"b\": 2,\n  \"c\": 3,\n  \"d\": 4,\n  \"e\": 5\n}\n"
+	modified := "{\n  \"a\": 1,\n  \"b\": 20,\n  \"c\": 3,\n  \"d\": 4,\n  \"e\": 5\n}\n"
+
+	opts := DefaultOptions()
+	opts.Context = 1
+	opts.FromFile = "a/config.json"
+	opts.ToFile = "b/config.json"
+
+	var b strings.Builder
+	if err := WriteDiff(&b, original, modified, opts); err != nil {
+		t.Fatalf("WriteDiff() error = %v", err)
+	}
+
+	want := "--- a/config.json\n" +
+		"+++ b/config.json\n" +
+		"@@ -2,3 +2,3 @@\n" +
+		"   \"a\": 1,\n" +
+		"-  \"b\": 2,\n" +
+		"+  \"b\": 20,\n" +
+		"   \"c\": 3,\n"
+	if b.String() != want {
+		t.Errorf("WriteDiff() =\n%s\nwant\n%s", b.String(), want)
+	}
+}
+
+func TestWriteDiffNoChanges(t *testing.T) {
+	var b strings.Builder
+	if err := WriteDiff(&b, "{}\n", "{}\n", DefaultOptions()); err != nil {
+		t.Fatalf("WriteDiff() error = %v", err)
+	}
+	if b.Len() != 0 {
+		t.Errorf("WriteDiff() = %q, want empty output", b.String())
+	}
+}
+
+func TestParseColorMode(t *testing.T) {
+	for _, s := range []string{"auto", "always", "never"} {
+		if _, err := ParseColorMode(s); err != nil {
+			t.Errorf("ParseColorMode(%q) error = %v", s, err)
+		}
+	}
+	if _, err := ParseColorMode("sometimes"); err == nil {
+		t.Error("ParseColorMode(\"sometimes\") expected error")
+	}
+}
@@ -0,0 +1,127 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tidwall/gjson"
+	jsonfile "github.com/vampire/je/internal/json"
+)
+
+// Change operation types reported for each modified path.
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+)
+
+// Change describes a single path that differs between two JSON documents.
+type Change struct {
+	Path string          `json:"path"`
+	Op   string          `json:"op"`
+	Old  json.RawMessage `json:"old,omitempty"`
+	New  json.RawMessage `json:"new,omitempty"`
+}
+
+// FileReport lists the changes made to one file.
+type FileReport struct {
+	File    string   `json:"file"`
+	Changes []Change `json:"changes"`
+}
+
+// NewFileReport builds the change report for a file from its original and modified JSON.
+func NewFileReport(filename string, original, modified []byte) FileReport {
+	return FileReport{
+		File:    filename,
+		Changes: Changes(original, modified),
+	}
+}
+
+// Changes returns every path that was added, removed or replaced between two JSON documents.
+// Paths use the same dotted syntax as assignments, with special characters escaped.
+func Changes(original, modified []byte) []Change {
+	changes := []Change{}
+	compareValues(&changes, "", gjson.ParseBytes(original), gjson.ParseBytes(modified))
+	return changes
+}
+
+// WriteJSONReport writes the reports as an indented JSON array to w.
+func WriteJSONReport(w io.Writer, reports []FileReport) error {
+	if reports == nil {
+		reports = []FileReport{}
+	}
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+func compareValues(changes *[]Change, path string, oldVal, newVal gjson.Result) {
+	switch {
+	case oldVal.IsObject() && newVal.IsObject():
+		compareObjects(changes, path, oldVal, newVal)
+	case oldVal.IsArray() && newVal.IsArray():
+		compareArrays(changes, path, oldVal, newVal)
+	case !jsonfile.EqualJSON([]byte(oldVal.Raw), []byte(newVal.Raw)):
+		*changes = append(*changes, Change{
+			Path: path,
+			Op:   OpReplace,
+			Old:  rawValue(oldVal),
+			New:  rawValue(newVal),
+		})
+	}
+}
+
+func compareObjects(changes *[]Change, path string, oldVal, newVal gjson.Result) {
+	newMap := newVal.Map()
+	oldVal.ForEach(func(key, value gjson.Result) bool {
+		childPath := jsonfile.JoinPath(path, key.String())
+		if next, ok := newMap[key.String()]; ok {
+			compareValues(changes, childPath, value, next)
+		} else {
+			*changes = append(*changes, Change{Path: childPath, Op: OpRemove, Old: rawValue(value)})
+		}
+		return true
+	})
+
+	oldMap := oldVal.Map()
+	newVal.ForEach(func(key, value gjson.Result) bool {
+		if _, ok := oldMap[key.String()]; !ok {
+			childPath := jsonfile.JoinPath(path, key.String())
+			*changes = append(*changes, Change{Path: childPath, Op: OpAdd, New: rawValue(value)})
+		}
+		return true
+	})
+}
+
+func compareArrays(changes *[]Change, path string, oldVal, newVal gjson.Result) {
+	oldItems := oldVal.Array()
+	newItems := newVal.Array()
+
+	for i := 0; i < len(oldItems) || i < len(newItems); i++ {
+		childPath := jsonfile.JoinPath(path, fmt.Sprint(i))
+		switch {
+		case i >= len(newItems):
+			*changes = append(*changes, Change{Path: childPath, Op: OpRemove, Old: rawValue(oldItems[i])})
+		case i >= len(oldItems):
+			*changes = append(*changes, Change{Path: childPath, Op: OpAdd, New: rawValue(newItems[i])})
+		default:
+			compareValues(changes, childPath, oldItems[i], newItems[i])
+		}
+	}
+}
+
+func rawValue(value gjson.Result) json.RawMessage {
+	if !value.Exists() {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, []byte(value.Raw)); err != nil {
+		return json.RawMessage(value.Raw)
+	}
+	return json.RawMessage(buf.Bytes())
+}
@@ -0,0 +1,48 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestChanges(t *testing.T) {
+	original := `{"name": "old", "port": 80, "tags": ["a"], "gone": true, "a.b": 1, "a|b": 1}`
+	modified := `{"name": "new", "port": 80, "tags": ["a", "b"], "added": {"x": null}, "a.b": 2, "a|b": 2}`
+
+	got := Changes([]byte(original), []byte(modified))
+	want := []Change{
+		{Path: "name", Op: OpReplace, Old: json.RawMessage(`"old"`), New: json.RawMessage(`"new"`)},
+		{Path: "tags.1", Op: OpAdd, New: json.RawMessage(`"b"`)},
+		{Path: "gone", Op: OpRemove, Old: json.RawMessage(`true`)},
+		{Path: `a\.b`, Op: OpReplace, Old: json.RawMessage(`1`), New: json.RawMessage(`2`)},
+		{Path: `a\|b`, Op: OpReplace, Old: json.RawMessage(`1`), New: json.RawMessage(`2`)},
+		{Path: "added", Op: OpAdd, New: json.RawMessage(`{"x":null}`)},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("Changes() returned %d changes, want %d: %+v", len(got), len(want), got)
+	}
+	for i := range want {
+		if got[i].Path != want[i].Path || got[i].Op != want[i].Op ||
+			string(got[i].Old) != string(want[i].Old) || string(got[i].New) != string(want[i].New) {
+			t.Errorf("Changes()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestWriteJSONReport(t *testing.T) {
+	var buf bytes.Buffer
+	report := NewFileReport("config.json", []byte(`{"a":1}`), []byte(`{"a":2}`))
+	if err := WriteJSONReport(&buf, []FileReport{report}); err != nil {
+		t.Fatalf("WriteJSONReport() error = %v", err)
+	}
+
+	var decoded []FileReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("report is not valid JSON: %v", err)
+	}
+	if len(decoded) != 1 || decoded[0].File != "config.json" || len(decoded[0].Changes) != 1 {
+		t.Errorf("unexpected report: %s", buf.String())
+	}
+}
@@ -0,0 +1,114 @@
+package glob
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// gitIgnore applies the rules of .gitignore files found while walking.
+// Only files at or below the walk root are read.
+type gitIgnore struct {
+	rules map[string][]ignoreRule // Rules by the directory holding the .gitignore
+}
+
+type ignoreRule struct {
+	pattern  string
+	negate   bool // A leading ! re-includes matching paths
+	dirOnly  bool // A trailing / matches directories only
+	anchored bool // A slash other than a trailing one anchors the pattern to its directory
+}
+
+func newGitIgnore() *gitIgnore {
+	return &gitIgnore{rules: make(map[string][]ignoreRule)}
+}
+
+// load reads the .gitignore in dir, if there is one.
+func (g *gitIgnore) load(dir string) error {
+	f, err := os.Open(filepath.Join(filepath.FromSlash(dir), ".gitignore"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var rules []ignoreRule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		rule.pattern = strings.TrimPrefix(line, `\`)
+		rules = append(rules, rule)
+	}
+	if len(rules) > 0 {
+		g.rules[dir] = rules
+	}
+	return scanner.Err()
+}
+
+// ignored reports whether name is ignored by the rules loaded so far. As in
+// git, the last matching rule wins and deeper .gitignore files take
+// precedence over shallower ones.
+func (g *gitIgnore) ignored(name string, isDir bool) bool {
+	if g == nil {
+		return false
+	}
+
+	result := false
+	for _, dir := range ancestors(name) {
+		rel := name
+		if dir != "." {
+			rel = strings.TrimPrefix(name, strings.TrimSuffix(dir, "/")+"/")
+		}
+		for _, rule := range g.rules[dir] {
+			if rule.matches(rel, isDir) {
+				result = !rule.negate
+			}
+		}
+	}
+	return result
+}
+
+func (r ignoreRule) matches(rel string, isDir bool) bool {
+	if r.dirOnly && !isDir {
+		return false
+	}
+	if r.anchored {
+		ok, _ := Match(r.pattern, rel)
+		return ok
+	}
+	ok, _ := path.Match(r.pattern, path.Base(rel))
+	return ok
+}
+
+// ancestors returns the directories containing name, outermost first.
+func ancestors(name string) []string {
+	var dirs []string
+	for dir := path.Dir(name); ; dir = path.Dir(dir) {
+		dirs = append([]string{dir}, dirs...)
+		if dir == "." || dir == "/" {
+			return dirs
+		}
+	}
+}
@@ -0,0 +1,196 @@
+// Package glob expands file patterns for --each. Patterns use the
+// filepath.Match syntax per path segment, plus `**` as a whole segment
+// matching any number of directories. Matches can be filtered by exclude
+// patterns and by .gitignore files.
+package glob
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Options controls how patterns are expanded.
+type Options struct {
+	// Exclude drops files matching any of these patterns. A pattern without
+	// a slash is matched against each path segment, so "node_modules"
+	// excludes that directory wherever it appears.
+	Exclude []string
+
+	// GitIgnore skips files ignored by .gitignore files found while walking.
+	GitIgnore bool
+}
+
+// Expand returns the files matching patterns, in pattern order and then
+// lexical order, without duplicates. A pattern without wildcards is
+// returned as is, whether or not the file exists.
+func Expand(patterns []string, opts Options) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(file string) {
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, pattern := range patterns {
+		if !hasMeta(pattern) {
+			if !excluded(filepath.ToSlash(pattern), opts.Exclude) {
+				add(pattern)
+			}
+			continue
+		}
+		if _, err := Match(pattern, ""); err != nil {
+			return nil, err
+		}
+
+		matches, err := walk(pattern, opts)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			add(m)
+		}
+	}
+	return files, nil
+}
+
+// Match reports whether name matches pattern. Both use forward slashes.
+func Match(pattern, name string) (bool, error) {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) (bool, error) {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// Try every number of segments for **, including none
+			for i := 0; i <= len(name); i++ {
+				if ok, err := matchSegments(pattern[1:], name[i:]); ok || err != nil {
+					return ok, err
+				}
+			}
+			return false, nil
+		}
+		if len(name) == 0 {
+			return false, nil
+		}
+		ok, err := path.Match(pattern[0], name[0])
+		if !ok || err != nil {
+			return false, err
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0, nil
+}
+
+// walk matches pattern against the files under its longest literal prefix.
+// Only directories that can hold a match are read, so a pattern without **
+// stops at its own depth, and errors from other entries are ignored.
+func walk(pattern string, opts Options) ([]string, error) {
+	slashPattern := path.Clean(filepath.ToSlash(pattern))
+	segments := strings.Split(slashPattern, "/")
+	root := literalPrefix(slashPattern)
+
+	var ignore *gitIgnore
+	if opts.GitIgnore {
+		ignore = newGitIgnore()
+	}
+
+	var matches []string
+	err := filepath.WalkDir(filepath.FromSlash(root), func(p string, d fs.DirEntry, err error) error {
+		name := filepath.ToSlash(p)
+		if err != nil {
+			switch {
+			case name == root && errors.Is(err, fs.ErrNotExist):
+				return fs.SkipAll
+			case name != root && d != nil && d.IsDir() && !containsMatches(segments, name):
+				return fs.SkipDir
+			case name != root && (d == nil || !d.IsDir()):
+				if ok, _ := Match(slashPattern, name); !ok {
+					return nil
+				}
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			if name != root && (d.Name() == ".git" || !containsMatches(segments, name) ||
+				excluded(name, opts.Exclude) || ignore.ignored(name, true)) {
+				return fs.SkipDir
+			}
+			if ignore != nil {
+				return ignore.load(name)
+			}
+			return nil
+		}
+
+		if excluded(name, opts.Exclude) || ignore.ignored(name, false) {
+			return nil
+		}
+		if ok, _ := Match(slashPattern, name); ok {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+	return matches, err
+}
+
+// containsMatches reports whether files below the directory dir can match
+// the pattern segments.
+func containsMatches(pattern []string, dir string) bool {
+	for _, segment := range strings.Split(dir, "/") {
+		if len(pattern) == 0 {
+			return false
+		}
+		if pattern[0] == "**" {
+			return true
+		}
+		if ok, _ := path.Match(pattern[0], segment); !ok {
+			return false
+		}
+		pattern = pattern[1:]
+	}
+	return len(pattern) > 0
+}
+
+// literalPrefix returns the leading directories of pattern that contain no
+// wildcards, or "." if there are none.
+func literalPrefix(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	n := 0
+	for n < len(segments)-1 && !hasMeta(segments[n]) {
+		n++
+	}
+	switch {
+	case n == 0:
+		return "."
+	case n == 1 && segments[0] == "":
+		return "/"
+	}
+	return strings.Join(segments[:n], "/")
+}
+
+func excluded(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		if strings.Contains(pattern, "/") {
+			if ok, _ := Match(path.Clean(pattern), name); ok {
+				return true
+			}
+			continue
+		}
+		for _, segment := range strings.Split(name, "/") {
+			if ok, _ := path.Match(pattern, segment); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[`)
+}
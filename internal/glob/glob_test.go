@@ -0,0 +1,169 @@
+package glob
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.json", "a.json", true},
+		{"*.json", "dir/a.json", false},
+		{"**/*.json", "a.json", true},
+		{"**/*.json", "a/b/c.json", true},
+		{"packages/**/package.json", "packages/x/package.json", true},
+		{"packages/**/package.json", "packages/package.json", true},
+		{"packages/**/package.json", "other/x/package.json", false},
+		{"a/**", "a/b/c", true},
+		{"a/**/b/*.json", "a/x/y/b/c.json", true},
+	}
+	for _, tt := range tests {
+		if got, err := Match(tt.pattern, tt.name); got != tt.want || err != nil {
+			t.Errorf("Match(%q, %q) = %v, %v, want %v", tt.pattern, tt.name, got, err, tt.want)
+		}
+	}
+}
+
+func TestExpand(t *testing.T) {
+	dir := t.TempDir()
+	for _, f := range []string{
+		"a.json",
+		"pkg/one/package.json",
+		"pkg/two/package.json",
+		"pkg/node_modules/dep/package.json",
+		"build/out.json",
+		"build/keep.json",
+		"notes.txt",
+	} {
+		writeFile(t, filepath.Join(dir, f), "{}")
+	}
+	writeFile(t, filepath.Join(dir, ".gitignore"), "# output\nbuild/*\n!build/keep.json\n")
+	t.Chdir(dir)
+
+	tests := []struct {
+		name     string
+		patterns []string
+		opts     Options
+		want     []string
+	}{
+		{
+			name:     "recursive",
+			patterns: []string{"**/*.json"},
+			want:     []string{"a.json", "build/keep.json", "build/out.json", "pkg/node_modules/dep/package.json", "pkg/one/package.json", "pkg/two/package.json"},
+		},
+		{
+			name:     "exclude by segment",
+			patterns: []string{"pkg/**/package.json"},
+			opts:     Options{Exclude: []string{"node_modules"}},
+			want:     []string{"pkg/one/package.json", "pkg/two/package.json"},
+		},
+		{
+			name:     "exclude by path pattern",
+			patterns: []string{"**/*.json"},
+			opts:     Options{Exclude: []string{"pkg/**", "a.json"}},
+			want:     []string{"build/keep.json", "build/out.json"},
+		},
+		{
+			name:     "gitignore with negation",
+			patterns: []string{"**/*.json"},
+			opts:     Options{GitIgnore: true, Exclude: []string{"pkg"}},
+			want:     []string{"a.json", "build/keep.json"},
+		},
+		{
+			name:     "literal and duplicate patterns",
+			patterns: []string{"missing.json", "*.json", "a.json"},
+			want:     []string{"missing.json", "a.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Expand(tt.patterns, tt.opts)
+			if err != nil {
+				t.Fatalf("Expand() error = %v", err)
+			}
+			for i := range got {
+				got[i] = filepath.ToSlash(got[i])
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestContainsMatches(t *testing.T) {
+	tests := []struct {
+		pattern, dir string
+		want         bool
+	}{
+		{"*.json", "sub", false},
+		{"*/*.json", "sub", true},
+		{"*/*.json", "sub/deeper", false},
+		{"pkg/*/package.json", "pkg/one", true},
+		{"pkg/*/package.json", "lib/one", false},
+		{"pkg/**/package.json", "pkg/a/b/c", true},
+		{"**/*.json", "any/depth", true},
+	}
+	for _, tt := range tests {
+		if got := containsMatches(strings.Split(tt.pattern, "/"), tt.dir); got != tt.want {
+			t.Errorf("containsMatches(%q, %q) = %v, want %v", tt.pattern, tt.dir, got, tt.want)
+		}
+	}
+}
+
+func TestExpandSkipsUnneededDirectories(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.json"), "{}")
+	writeFile(t, filepath.Join(dir, "locked", "b.json"), "{}")
+	locked := filepath.Join(dir, "locked")
+	if err := os.Chmod(locked, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(locked, 0755) })
+	if _, err := os.ReadDir(locked); err == nil {
+		t.Skip("permissions are not enforced for this user")
+	}
+	t.Chdir(dir)
+
+	got, err := Expand([]string{"*.json"}, Options{})
+	if err != nil {
+		t.Fatalf("Expand() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, []string{"a.json"}) {
+		t.Errorf("Expand() = %v, want [a.json]", got)
+	}
+	if _, err := Expand([]string{"**/*.json"}, Options{}); err == nil {
+		t.Error("Expand() of a recursive pattern ignored an unreadable directory")
+	}
+}
+
+func TestReadList(t *testing.T) {
+	tests := map[string][]string{
+		"a.json\nb c.json\r\n\n":  {"a.json", "b c.json"},
+		"a.json\x00new\nline\x00": {"a.json", "new\nline"},
+		"":                        nil,
+	}
+	for input, want := range tests {
+		got, err := ReadList(strings.NewReader(input))
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("ReadList(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
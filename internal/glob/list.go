@@ -0,0 +1,46 @@
+package glob
+
+import (
+	"bytes"
+	"io"
+	"os"
+)
+
+// ReadList reads a list of file names, one per line or separated by NUL
+// bytes as written by `find -print0` and `git ls-files -z`. NUL separation
+// is used whenever the input contains a NUL. Empty names are dropped.
+func ReadList(r io.Reader) ([]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	sep := []byte("\n")
+	if bytes.IndexByte(data, 0) >= 0 {
+		sep = []byte{0}
+	}
+
+	var names []string
+	for _, name := range bytes.Split(data, sep) {
+		if sep[0] == '\n' {
+			name = bytes.TrimSuffix(name, []byte("\r"))
+		}
+		if len(name) > 0 {
+			names = append(names, string(name))
+		}
+	}
+	return names, nil
+}
+
+// ReadListFile reads a file list from filename, or from stdin for "-".
+func ReadListFile(filename string) ([]string, error) {
+	if filename == "-" {
+		return ReadList(os.Stdin)
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadList(f)
+}
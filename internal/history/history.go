@@ -0,0 +1,148 @@
+// Package history keeps a journal of in-place edits so they can be listed
+// and undone. Each edited file has a JSON Lines journal in a .je/history
+// directory next to it, one entry per write, holding the bytes the file had
+// before the write and the assignments that were applied.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	jsonfile "github.com/vampire/je/internal/json"
+	"github.com/vampire/je/internal/parser"
+)
+
+// Dir is the journal directory, relative to the edited file's directory.
+const Dir = ".je/history"
+
+// Entry records one write to a file.
+type Entry struct {
+	Time        time.Time `json:"time"`
+	Assignments []string  `json:"assignments"`
+	Existed     bool      `json:"existed"`  // Whether the file existed before the write
+	Original    []byte    `json:"original"` // Contents before the write
+}
+
+// ErrNoHistory is returned by Undo for a file with no recorded writes.
+var ErrNoHistory = errors.New("no history recorded")
+
+// JournalPath returns the journal file for path.
+func JournalPath(path string) string {
+	return filepath.Join(filepath.Dir(path), Dir, filepath.Base(path)+".jsonl")
+}
+
+// Record appends an entry for a write to path. original holds the contents
+// before the write and is nil if the file did not exist.
+func Record(path string, original []byte, assignments []parser.Assignment) error {
+	entry := Entry{
+		Time:        time.Now().UTC(),
+		Assignments: make([]string, len(assignments)),
+		Existed:     original != nil,
+		Original:    original,
+	}
+	for i, a := range assignments {
+		entry.Assignments[i] = a.String()
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	journal := JournalPath(path)
+	if err := os.MkdirAll(filepath.Dir(journal), 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	f, err := os.OpenFile(journal, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open history: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return f.Sync()
+}
+
+// History returns the recorded writes to path, oldest first.
+func History(path string) ([]Entry, error) {
+	f, err := os.Open(JournalPath(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<30)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("corrupt history entry on line %d: %w", lineNo, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Undo restores path to its contents before the most recent recorded write,
+// removing the file if that write created it, and drops the entry from the
+// journal. It returns the entry that was undone.
+func Undo(path string) (*Entry, error) {
+	entries, err := History(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoHistory)
+	}
+	last := entries[len(entries)-1]
+
+	if last.Existed {
+		perm := os.FileMode(0644)
+		if info, err := os.Stat(path); err == nil {
+			perm = info.Mode()
+		}
+		if err := jsonfile.AtomicWriteFile(path, last.Original, perm, jsonfile.WriteOptions{}); err != nil {
+			return nil, fmt.Errorf("failed to restore %s: %w", path, err)
+		}
+	} else if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to remove %s: %w", path, err)
+	}
+
+	if err := writeJournal(path, entries[:len(entries)-1]); err != nil {
+		return nil, err
+	}
+	return &last, nil
+}
+
+// writeJournal replaces the journal for path with entries.
+func writeJournal(path string, entries []Entry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := jsonfile.AtomicWriteFile(JournalPath(path), buf.Bytes(), 0600, jsonfile.WriteOptions{}); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestRecordAndUndo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assignments := []parser.Assignment{{Path: "port", Operator: parser.OpAssignJSON, Value: "80"}}
+
+	// A write that created the file, then one that changed it
+	if err := Record(path, nil, assignments); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"port":80}`), 0640); err != nil {
+		t.Fatal(err)
+	}
+	if err := Record(path, []byte(`{"port":80}`), assignments); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"port":81}`), 0640); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := History(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Assignments[0] != "port:=80" || entries[0].Existed {
+		t.Fatalf("History() = %+v", entries)
+	}
+
+	if _, err := Undo(path); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	got, _ := os.ReadFile(path)
+	if string(got) != `{"port":80}` {
+		t.Errorf("after undo = %s", got)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+
+	if _, err := Undo(path); err != nil {
+		t.Fatalf("Undo() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("undoing the creating write should remove the file, stat = %v", err)
+	}
+
+	if _, err := Undo(path); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Undo() with empty history = %v, want ErrNoHistory", err)
+	}
+}
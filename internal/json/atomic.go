@@ -0,0 +1,174 @@
+package json
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// maxSymlinks bounds symlink resolution, matching the usual kernel limit.
+const maxSymlinks = 40
+
+// WriteOptions controls how AtomicWriteFile replaces a file.
+type WriteOptions struct {
+	// Xattrs copies extended attributes from the file being replaced.
+	// It is only supported on Linux and ignored elsewhere.
+	Xattrs bool
+
+	// Expect, when set, is checked just before the rename; if the file has
+	// changed since the snapshot was taken the write is abandoned with a
+	// *ModifiedError.
+	Expect *Snapshot
+}
+
+// StagedFile is a fully written temp file waiting to replace its target.
+// Staging several files before committing any lets a caller replace them
+// all or none.
+type StagedFile struct {
+	Path      string // Path of the file being replaced, after resolving symlinks
+	tmp       string
+	expect    *Snapshot
+	committed bool
+}
+
+// AtomicWriteFile replaces path with data so readers see either the old or
+// the new contents, never a partial write. The data goes to a uniquely named
+// temp file in the same directory, which is synced and renamed over the
+// destination before the directory itself is synced. A symlink at path is
+// followed so its target is replaced and the link kept. The new file gets
+// perm and, where the platform allows, the owner and group of the file it
+// replaces.
+func AtomicWriteFile(path string, data []byte, perm os.FileMode, opts WriteOptions) error {
+	staged, err := StageFile(path, data, perm, opts)
+	if err != nil {
+		return err
+	}
+	if err := staged.Commit(); err != nil {
+		staged.Abort()
+		return err
+	}
+	return nil
+}
+
+// StageFile does everything AtomicWriteFile does except the final rename,
+// which is left to Commit.
+func StageFile(path string, data []byte, perm os.FileMode, opts WriteOptions) (*StagedFile, error) {
+	return StageStream(path, perm, opts, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// StageStream is StageFile for contents produced by write, which streams
+// them to the temp file instead of holding them in memory. An error from
+// write is returned as is and nothing is staged.
+func StageStream(path string, perm os.FileMode, opts WriteOptions, write func(io.Writer) error) (*StagedFile, error) {
+	target, err := resolveSymlinks(path)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := os.Stat(target)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), "."+filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	staged := false
+	defer func() {
+		if !staged {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if err := write(tmp); err != nil {
+		return nil, err
+	}
+	if err := tmp.Chmod(perm & (os.ModePerm | os.ModeSetuid | os.ModeSetgid | os.ModeSticky)); err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		if err := preserveOwner(tmp, existing); err != nil {
+			return nil, err
+		}
+		if opts.Xattrs {
+			if err := copyXattrs(target, tmp.Name()); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	staged = true
+	return &StagedFile{Path: target, tmp: tmp.Name(), expect: opts.Expect}, nil
+}
+
+// Commit renames the staged file over its target and syncs the directory.
+// If WriteOptions.Expect was set and the target has changed since, the
+// target is left alone and a *ModifiedError is returned.
+func (s *StagedFile) Commit() error {
+	if s.expect != nil {
+		if err := s.expect.Verify(s.Path); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(s.tmp, s.Path); err != nil {
+		return err
+	}
+	s.committed = true
+	return syncDir(filepath.Dir(s.Path))
+}
+
+// Committed reports whether Commit renamed the staged file over its target.
+// It is true even if Commit then failed to sync the directory, in which case
+// the target already holds the new contents.
+func (s *StagedFile) Committed() bool {
+	return s.committed
+}
+
+// Abort removes a staged file that will not be committed.
+func (s *StagedFile) Abort() error {
+	if err := os.Remove(s.tmp); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// resolveSymlinks follows symlinks at path, including a final link whose
+// target does not exist yet, and returns the path to write to.
+func resolveSymlinks(path string) (string, error) {
+	for range maxSymlinks {
+		info, err := os.Lstat(path)
+		if os.IsNotExist(err) {
+			return path, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return path, nil
+		}
+
+		link, err := os.Readlink(path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(link) {
+			link = filepath.Join(filepath.Dir(path), link)
+		}
+		path = link
+	}
+	return "", fmt.Errorf("%s: %w", path, errTooManySymlinks)
+}
+
+var errTooManySymlinks = errors.New("too many levels of symbolic links")
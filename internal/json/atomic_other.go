@@ -0,0 +1,14 @@
+//go:build !unix
+
+package json
+
+import "os"
+
+func preserveOwner(*os.File, os.FileInfo) error {
+	return nil
+}
+
+// syncDir is a no-op where directories cannot be opened for syncing.
+func syncDir(string) error {
+	return nil
+}
@@ -0,0 +1,99 @@
+package json
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAtomicWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"a":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AtomicWriteFile(path, []byte(`{"a":2}`), 0640, WriteOptions{Xattrs: true}); err != nil {
+		t.Fatalf("AtomicWriteFile() error = %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if string(got) != `{"a":2}` {
+		t.Errorf("contents = %s", got)
+	}
+	info, _ := os.Stat(path)
+	if info.Mode().Perm() != 0640 {
+		t.Errorf("mode = %v, want 0640", info.Mode().Perm())
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
+
+func TestAtomicWriteFileFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real.json")
+	link := filepath.Join(dir, "link.json")
+	dangling := filepath.Join(dir, "dangling.json")
+	if err := os.WriteFile(target, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("real.json", link); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	if err := os.Symlink("new.json", dangling); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AtomicWriteFile(link, []byte(`{"a":1}`), 0644, WriteOptions{}); err != nil {
+		t.Fatalf("AtomicWriteFile() error = %v", err)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("symlink was replaced by a regular file")
+	}
+	if got, _ := os.ReadFile(target); string(got) != `{"a":1}` {
+		t.Errorf("target contents = %s", got)
+	}
+
+	if err := AtomicWriteFile(dangling, []byte(`{}`), 0644, WriteOptions{}); err != nil {
+		t.Fatalf("AtomicWriteFile() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.json")); err != nil {
+		t.Errorf("dangling symlink target not created: %v", err)
+	}
+}
+
+func TestStageFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte(`{"a":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	staged, err := StageFile(path, []byte(`{"a":2}`), 0644, WriteOptions{})
+	if err != nil {
+		t.Fatalf("StageFile() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != `{"a":1}` || staged.Committed() {
+		t.Fatalf("staging replaced the file: %s", got)
+	}
+	if err := staged.Commit(); err != nil {
+		t.Fatalf("Commit() error = %v", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != `{"a":2}` || !staged.Committed() {
+		t.Errorf("contents after Commit() = %s, Committed() = %v", got, staged.Committed())
+	}
+
+	aborted, err := StageFile(path, []byte(`{"a":3}`), 0644, WriteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := aborted.Abort(); err != nil {
+		t.Fatalf("Abort() error = %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("temp files left behind: %v", entries)
+	}
+}
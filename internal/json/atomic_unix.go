@@ -0,0 +1,37 @@
+//go:build unix
+
+package json
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// preserveOwner gives f the owner and group of existing. Only root may give
+// a file away, so a permission error is not fatal: the file is then owned by
+// the current user, as any newly created file would be.
+func preserveOwner(f *os.File, existing os.FileInfo) error {
+	stat, ok := existing.Sys().(*syscall.Stat_t)
+	if !ok {
+		return nil
+	}
+	err := f.Chown(int(stat.Uid), int(stat.Gid))
+	if errors.Is(err, os.ErrPermission) {
+		return nil
+	}
+	return err
+}
+
+// syncDir flushes a directory entry change such as a rename to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	if err := d.Sync(); err != nil && !errors.Is(err, syscall.EINVAL) {
+		return err
+	}
+	return nil
+}
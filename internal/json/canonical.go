@@ -0,0 +1,185 @@
+package json
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/tidwall/gjson"
+)
+
+// Canonicalize encodes JSON in the RFC 8785 JSON Canonicalization Scheme:
+// no whitespace, object keys sorted by UTF-16 code units, numbers in their
+// shortest ECMAScript form and strings with minimal escaping.
+func Canonicalize(data []byte) ([]byte, error) {
+	if !gjson.ValidBytes(data) {
+		return nil, Validate(data)
+	}
+
+	var b bytes.Buffer
+	if err := writeCanonical(&b, gjson.ParseBytes(data)); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// Hash returns the hex SHA-256 digest of the canonical form of a document, or
+// of the value at path when path is not empty.
+func Hash(data []byte, path string) (string, error) {
+	if path != "" {
+		if !gjson.ValidBytes(data) {
+			return "", Validate(data)
+		}
+		value := gjson.GetBytes(data, path)
+		if !value.Exists() {
+			return "", fmt.Errorf("path %q does not exist", path)
+		}
+		data = []byte(value.Raw)
+	}
+
+	canonical, err := Canonicalize(data)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func writeCanonical(b *bytes.Buffer, value gjson.Result) error {
+	switch {
+	case value.IsObject():
+		return writeCanonicalObject(b, value)
+	case value.IsArray():
+		b.WriteByte('[')
+		for i, item := range value.Array() {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if err := writeCanonical(b, item); err != nil {
+				return err
+			}
+		}
+		b.WriteByte(']')
+		return nil
+	}
+
+	switch value.Type {
+	case gjson.String:
+		writeCanonicalString(b, value.Str)
+	case gjson.Number:
+		n, err := canonicalNumber(value.Raw)
+		if err != nil {
+			return err
+		}
+		b.WriteString(n)
+	default:
+		b.WriteString(value.Raw)
+	}
+	return nil
+}
+
+func writeCanonicalObject(b *bytes.Buffer, value gjson.Result) error {
+	type member struct {
+		key   string
+		units []uint16
+		value gjson.Result
+	}
+
+	// Duplicate keys keep the last value, as in a parsed object.
+	index := map[string]int{}
+	var members []member
+	value.ForEach(func(key, field gjson.Result) bool {
+		if i, ok := index[key.Str]; ok {
+			members[i].value = field
+			return true
+		}
+		index[key.Str] = len(members)
+		members = append(members, member{key: key.Str, units: utf16.Encode([]rune(key.Str)), value: field})
+		return true
+	})
+
+	sort.Slice(members, func(i, j int) bool { return lessUTF16(members[i].units, members[j].units) })
+
+	b.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		writeCanonicalString(b, m.key)
+		b.WriteByte(':')
+		if err := writeCanonical(b, m.value); err != nil {
+			return err
+		}
+	}
+	b.WriteByte('}')
+	return nil
+}
+
+func lessUTF16(a, b []uint16) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// writeCanonicalString escapes only quotes, backslashes and control characters,
+// using the short forms where JSON defines them.
+func writeCanonicalString(b *bytes.Buffer, s string) {
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+}
+
+// canonicalNumber formats a number the way ECMAScript's Number.prototype.toString
+// does for IEEE 754 doubles, as RFC 8785 requires. Integers beyond 2^53 lose
+// precision, exactly as they would in any other JCS implementation.
+func canonicalNumber(raw string) (string, error) {
+	f, err := strconv.ParseFloat(raw, 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return "", fmt.Errorf("number %s cannot be represented as an IEEE 754 double", raw)
+	}
+	if f == 0 {
+		return "0", nil
+	}
+
+	abs := math.Abs(f)
+	if abs >= 1e21 || abs < 1e-6 {
+		s := strconv.FormatFloat(f, 'e', -1, 64)
+		mantissa, exp, _ := strings.Cut(s, "e")
+		sign := exp[0]
+		exp = strings.TrimLeft(exp[1:], "0")
+		return mantissa + "e" + string(sign) + exp, nil
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64), nil
+}
@@ -0,0 +1,71 @@
+package json
+
+import "testing"
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name:     "whitespace and key order",
+			input:    "{\n  \"b\": [1, 2],\n  \"a\": {\"y\": true, \"x\": null}\n}",
+			expected: `{"a":{"x":null,"y":true},"b":[1,2]}`,
+		},
+		{
+			name:     "numbers",
+			input:    `[1.0, 4.50, 2e-3, 1e30, 0.000000000000000000000000001, -0, 333333333.33333329, 1E+2]`,
+			expected: `[1,4.5,0.002,1e+30,1e-27,0,333333333.3333333,100]`,
+		},
+		{
+			name:     "string escapes",
+			input:    `"\u0041\u00e9\/\u001f\n\u2028"`,
+			expected: "\"A\u00e9/\\u001f\\n\u2028\"",
+		},
+		{
+			name:     "keys sorted by UTF-16 code units",
+			input:    `{"\u20ac":1,"\r":2,"\ufb33":3,"1":4,"\ud83d\ude00":5,"\u0080":6,"\u00f6":7}`,
+			expected: "{\"\\r\":2,\"1\":4,\"\u0080\":6,\"\u00f6\":7,\"\u20ac\":1,\"\U0001F600\":5,\"\ufb33\":3}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Canonicalize([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Canonicalize() error = %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Canonicalize() = %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHash(t *testing.T) {
+	a, err := Hash([]byte(`{"b": 1, "a": {"x": [1.0]}}`), "")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	b, err := Hash([]byte(`{"a":{"x":[1]},"b":1}`), "")
+	if err != nil {
+		t.Fatalf("Hash() error = %v", err)
+	}
+	if a != b {
+		t.Errorf("Hash() differs for equivalent documents: %s != %s", a, b)
+	}
+
+	sub, err := Hash([]byte(`{"a":{"x":[1]},"b":1}`), "a")
+	if err != nil {
+		t.Fatalf("Hash() with path error = %v", err)
+	}
+	want, _ := Hash([]byte(`{"x":[1]}`), "")
+	if sub != want {
+		t.Errorf("Hash() of subtree = %s, want %s", sub, want)
+	}
+
+	if _, err := Hash([]byte(`{}`), "missing"); err == nil {
+		t.Error("Hash() expected error for missing path")
+	}
+}
@@ -0,0 +1,102 @@
+package json
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// FileFormat identifies the on-disk encoding of a document.
+// Documents are always edited as JSON; other formats are converted on read and write.
+type FileFormat int
+
+const (
+	FormatAuto FileFormat = iota // Detect from the file extension
+	FormatJSON
+	FormatYAML
+	FormatTOML
+)
+
+// codec converts between a file format and JSON.
+type codec interface {
+	// Decode converts a document to JSON.
+	Decode(data []byte) ([]byte, error)
+	// Encode converts JSON back to the file format. original holds the
+	// previous contents of the destination, if any, so formatting and
+	// comments can be preserved.
+	Encode(data, original []byte) ([]byte, error)
+}
+
+var codecs = map[FileFormat]codec{
+	FormatYAML: yamlCodec{},
+	FormatTOML: tomlCodec{},
+}
+
+// ParseFileFormat parses a --format flag value.
+func ParseFileFormat(s string) (FileFormat, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return FormatAuto, nil
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "toml":
+		return FormatTOML, nil
+	default:
+		return FormatAuto, fmt.Errorf("unsupported format %q", s)
+	}
+}
+
+// DetectFormat returns the format implied by a file's extension, defaulting to JSON.
+func DetectFormat(path string) FileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return FormatYAML
+	case ".toml":
+		return FormatTOML
+	default:
+		return FormatJSON
+	}
+}
+
+// ResolveFormat picks the format for path, honoring an explicit override.
+// Standard input and output ("-") default to JSON.
+func ResolveFormat(path string, format FileFormat) FileFormat {
+	if format != FormatAuto {
+		return format
+	}
+	if path == "-" {
+		return FormatJSON
+	}
+	return DetectFormat(path)
+}
+
+// OutputFormat picks the format for writing a document read from source to
+// dest. An explicit format wins; otherwise dest's extension is used when it
+// names a format, and the source's format when it does not, so writing
+// config.yaml to stdout or to an extensionless file stays YAML.
+func OutputFormat(source, dest string, format FileFormat) FileFormat {
+	if format != FormatAuto {
+		return format
+	}
+	switch strings.ToLower(filepath.Ext(dest)) {
+	case ".json", ".yaml", ".yml", ".toml":
+		return DetectFormat(dest)
+	}
+	return ResolveFormat(source, format)
+}
+
+// String returns the flag name of the format.
+func (f FileFormat) String() string {
+	switch f {
+	case FormatJSON:
+		return "json"
+	case FormatYAML:
+		return "yaml"
+	case FormatTOML:
+		return "toml"
+	default:
+		return "auto"
+	}
+}
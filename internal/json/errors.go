@@ -0,0 +1,189 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSnippetWidth bounds the source line shown in a SyntaxError.
+const maxSnippetWidth = 80
+
+// SyntaxError describes invalid JSON with its position in the source.
+type SyntaxError struct {
+	File   string // Source name; empty when unknown
+	Line   int    // 1-based line number
+	Column int    // 1-based column, in characters
+	Msg    string // Parser message
+	Source string // The offending line
+	Caret  int    // Character offset of the error within Source
+	Hint   string // Likely cause, if one could be determined
+}
+
+func (e *SyntaxError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		fmt.Fprintf(&b, "%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	} else {
+		fmt.Fprintf(&b, "line %d, column %d: %s", e.Line, e.Column, e.Msg)
+	}
+	fmt.Fprintf(&b, "\n    %s\n    %s^", e.Source, caretPadding(e.Source, e.Caret))
+	if e.Hint != "" {
+		fmt.Fprintf(&b, "\n  hint: %s", e.Hint)
+	}
+	return b.String()
+}
+
+// ValidateFile checks that data is valid JSON, reporting problems as a
+// *SyntaxError that names the file, line and column.
+func ValidateFile(data []byte, name string) error {
+	var raw json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err == nil {
+		return nil
+	}
+
+	var syntaxErr *json.SyntaxError
+	if !errors.As(err, &syntaxErr) {
+		return err
+	}
+	return newSyntaxError(data, name, syntaxErr)
+}
+
+func newSyntaxError(data []byte, name string, err *json.SyntaxError) *SyntaxError {
+	eof := err.Offset >= int64(len(data)) && strings.Contains(err.Error(), "unexpected end")
+
+	// The offset counts the offending byte; at end of input point just past
+	// the last meaningful character instead of at trailing whitespace.
+	pos := int(err.Offset) - 1
+	if eof {
+		pos = len(bytes.TrimRight(data, " \t\r\n"))
+	}
+	pos = max(0, min(pos, len(data)))
+
+	lineStart := bytes.LastIndexByte(data[:pos], '\n') + 1
+	lineEnd := len(data)
+	if i := bytes.IndexByte(data[pos:], '\n'); i >= 0 {
+		lineEnd = pos + i
+	}
+	line := strings.TrimRight(string(data[lineStart:lineEnd]), "\r")
+	column := utf8.RuneCount(data[lineStart:pos])
+	source, caret := clipLine(line, column)
+
+	return &SyntaxError{
+		File:   name,
+		Line:   bytes.Count(data[:pos], []byte("\n")) + 1,
+		Column: column + 1,
+		Msg:    err.Error(),
+		Source: source,
+		Caret:  caret,
+		Hint:   syntaxHint(data, pos, eof, err.Error()),
+	}
+}
+
+// syntaxHint guesses the cause of a syntax error from the surrounding text.
+func syntaxHint(data []byte, pos int, eof bool, msg string) string {
+	if eof {
+		return unclosedHint(data)
+	}
+
+	c := data[pos]
+	prev := bytes.TrimRight(data[:pos], " \t\r\n")
+	switch {
+	case (c == '}' || c == ']') && bytes.HasSuffix(prev, []byte(",")):
+		return fmt.Sprintf("remove the trailing comma before '%c'", c)
+	case c == '\'':
+		return "strings and keys must use double quotes"
+	case c == '/' || c == '#':
+		return "comments are not allowed in JSON"
+	case strings.Contains(msg, "looking for beginning of object key string"):
+		return "object keys must be double-quoted strings"
+	case strings.Contains(msg, "after object key:value pair"):
+		return "missing ',' between object members or a closing '}'"
+	case strings.Contains(msg, "after array element"):
+		return "missing ',' between array elements or a closing ']'"
+	case strings.Contains(msg, "after object key"):
+		return "missing ':' after object key"
+	}
+	return ""
+}
+
+// unclosedHint names the brackets left open at the end of the input.
+func unclosedHint(data []byte) string {
+	var stack []byte
+	inString, escaped := false, false
+	for _, c := range data {
+		switch {
+		case inString:
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+		case c == '"':
+			inString = true
+		case c == '{':
+			stack = append(stack, '}')
+		case c == '[':
+			stack = append(stack, ']')
+		case (c == '}' || c == ']') && len(stack) > 0:
+			stack = stack[:len(stack)-1]
+		}
+	}
+
+	switch {
+	case inString:
+		return "unterminated string"
+	case len(stack) > 0:
+		var missing []string
+		for i := len(stack) - 1; i >= 0; i-- {
+			missing = append(missing, fmt.Sprintf("'%c'", stack[i]))
+		}
+		return "missing closing " + strings.Join(missing, ", ")
+	}
+	return ""
+}
+
+// clipLine shortens long lines to a window around the error column.
+func clipLine(line string, column int) (clipped string, caret int) {
+	runes := []rune(line)
+	if len(runes) <= maxSnippetWidth {
+		return line, column
+	}
+	start := max(0, min(column-maxSnippetWidth/2, len(runes)-maxSnippetWidth))
+	end := min(len(runes), start+maxSnippetWidth)
+	clipped, caret = string(runes[start:end]), column-start
+	if start > 0 {
+		clipped, caret = "..."+clipped, caret+3
+	}
+	if end < len(runes) {
+		clipped += "..."
+	}
+	return clipped, caret
+}
+
+// caretPadding returns whitespace lining a caret up under the given character,
+// keeping tabs so the alignment survives terminal tab stops.
+func caretPadding(source string, caret int) string {
+	var b strings.Builder
+	for i, r := range []rune(source) {
+		if i >= caret {
+			break
+		}
+		if r == '\t' {
+			b.WriteRune('\t')
+		} else {
+			b.WriteByte(' ')
+		}
+	}
+	for i := utf8.RuneCountInString(source); i < caret; i++ {
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateFile(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		line   int
+		column int
+		hint   string
+	}{
+		{
+			name:   "trailing comma",
+			input:  "{\n  \"a\": 1,\n  \"b\": 2,\n}",
+			line:   4,
+			column: 1,
+			hint:   "trailing comma",
+		},
+		{
+			name:   "unquoted key",
+			input:  "{\n  name: \"x\"\n}",
+			line:   2,
+			column: 3,
+			hint:   "double-quoted",
+		},
+		{
+			name:   "missing brace",
+			input:  "{\n  \"a\": {\"b\": [1, 2]\n",
+			line:   2,
+			column: 20,
+			hint:   "missing closing '}', '}'",
+		},
+		{
+			name:   "missing comma",
+			input:  `{"a": 1 "b": 2}`,
+			line:   1,
+			column: 9,
+			hint:   "missing ','",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFile([]byte(tt.input), "config.json")
+			var syntaxErr *SyntaxError
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("ValidateFile() error = %v, want *SyntaxError", err)
+			}
+			if syntaxErr.Line != tt.line || syntaxErr.Column != tt.column {
+				t.Errorf("position = %d:%d, want %d:%d", syntaxErr.Line, syntaxErr.Column, tt.line, tt.column)
+			}
+			if !strings.Contains(syntaxErr.Hint, tt.hint) {
+				t.Errorf("hint = %q, want it to contain %q", syntaxErr.Hint, tt.hint)
+			}
+			if !strings.HasPrefix(err.Error(), "config.json:") {
+				t.Errorf("error %q does not name the file", err.Error())
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorSnippet(t *testing.T) {
+	err := ValidateFile([]byte("{\"a\": tru}"), "")
+	want := "line 1, column 10: invalid character '}' in literal true (expecting 'e')\n" +
+		"    {\"a\": tru}\n" +
+		"             ^"
+	if err == nil || err.Error() != want {
+		t.Errorf("Error() =\n%v\nwant\n%s", err, want)
+	}
+}
+
+func TestValidateFileValid(t *testing.T) {
+	if err := ValidateFile([]byte(`{"a": [1, 2]}`), "x.json"); err != nil {
+		t.Errorf("ValidateFile() error = %v", err)
+	}
+}
@@ -0,0 +1,179 @@
+package json
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// FormatOptions controls how a document is re-encoded.
+type FormatOptions struct {
+	Indent          string // Indentation per level; empty produces compact output
+	SortKeys        bool   // Sort object keys instead of keeping document order
+	EscapeHTML      bool   // Escape <, > and & as \u003c-style sequences
+	ASCII           bool   // Escape all non-ASCII characters as \uXXXX
+	TrailingNewline bool   // End the output with a newline
+}
+
+// DefaultIndent is used when pretty printing a document that has no indentation to detect.
+const DefaultIndent = "  "
+
+// FormatWith re-encodes JSON according to opts. Key order, numbers and string
+// escapes are kept as written unless an option requires changing them.
+func FormatWith(data []byte, opts FormatOptions) ([]byte, error) {
+	if !gjson.ValidBytes(data) {
+		return nil, Validate(data)
+	}
+
+	var b bytes.Buffer
+	writeValue(&b, gjson.ParseBytes(data), opts, 0)
+	if opts.TrailingNewline {
+		b.WriteByte('\n')
+	}
+	return b.Bytes(), nil
+}
+
+// DetectIndent returns the indentation unit of a document: a run of spaces or
+// a tab, taken from the first indented line. It returns an empty string for
+// documents on a single line.
+func DetectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n")[1:] {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || len(trimmed) == len(line) {
+			continue
+		}
+		indent := line[:len(line)-len(trimmed)]
+		if strings.HasPrefix(indent, "\t") {
+			return "\t"
+		}
+		return strings.TrimRight(indent, "\t")
+	}
+	return ""
+}
+
+// HasTrailingNewline reports whether a document ends with a newline.
+func HasTrailingNewline(data []byte) bool {
+	return bytes.HasSuffix(data, []byte("\n"))
+}
+
+func writeValue(b *bytes.Buffer, value gjson.Result, opts FormatOptions, depth int) {
+	switch {
+	case value.IsObject():
+		writeObject(b, value, opts, depth)
+	case value.IsArray():
+		items := value.Array()
+		if len(items) == 0 {
+			b.WriteString("[]")
+			return
+		}
+		b.WriteByte('[')
+		for i, item := range items {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			writeNewline(b, opts, depth+1)
+			writeValue(b, item, opts, depth+1)
+		}
+		writeNewline(b, opts, depth)
+		b.WriteByte(']')
+	case value.Type == gjson.String:
+		writeString(b, value, opts)
+	default:
+		b.WriteString(value.Raw)
+	}
+}
+
+func writeObject(b *bytes.Buffer, value gjson.Result, opts FormatOptions, depth int) {
+	var keys, values []gjson.Result
+	value.ForEach(func(key, field gjson.Result) bool {
+		keys = append(keys, key)
+		values = append(values, field)
+		return true
+	})
+	if len(keys) == 0 {
+		b.WriteString("{}")
+		return
+	}
+
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	if opts.SortKeys {
+		sort.SliceStable(order, func(i, j int) bool { return keys[order[i]].Str < keys[order[j]].Str })
+	}
+
+	b.WriteByte('{')
+	for n, i := range order {
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		writeNewline(b, opts, depth+1)
+		writeString(b, keys[i], opts)
+		b.WriteByte(':')
+		if opts.Indent != "" {
+			b.WriteByte(' ')
+		}
+		writeValue(b, values[i], opts, depth+1)
+	}
+	writeNewline(b, opts, depth)
+	b.WriteByte('}')
+}
+
+func writeNewline(b *bytes.Buffer, opts FormatOptions, depth int) {
+	if opts.Indent == "" {
+		return
+	}
+	b.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		b.WriteString(opts.Indent)
+	}
+}
+
+// writeString writes a string token, re-escaping it only when an escaping
+// option applies to its contents.
+func writeString(b *bytes.Buffer, value gjson.Result, opts FormatOptions) {
+	if !needsEscape(value.Str, opts) {
+		b.WriteString(value.Raw)
+		return
+	}
+
+	quoted := QuoteString(value.Str)
+	for _, r := range quoted {
+		switch {
+		case opts.EscapeHTML && (r == '<' || r == '>' || r == '&'):
+			fmt.Fprintf(b, `\u%04x`, r)
+		case opts.ASCII && r >= utf8.RuneSelf:
+			writeUnicodeEscape(b, r)
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+func needsEscape(s string, opts FormatOptions) bool {
+	if opts.EscapeHTML && strings.ContainsAny(s, "<>&") {
+		return true
+	}
+	if opts.ASCII {
+		for i := 0; i < len(s); i++ {
+			if s[i] >= utf8.RuneSelf {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func writeUnicodeEscape(b *bytes.Buffer, r rune) {
+	if r > 0xFFFF {
+		r -= 0x10000
+		fmt.Fprintf(b, `\u%04x\u%04x`, 0xD800+(r>>10), 0xDC00+(r&0x3FF))
+		return
+	}
+	fmt.Fprintf(b, `\u%04x`, r)
+}
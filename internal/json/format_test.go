@@ -0,0 +1,77 @@
+package json
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		pretty   bool
+		compact  bool
+		expected string
+	}{
+		{
+			name:     "pretty keeps key order and escapes",
+			input:    `{"z":1,"a":"<b>&amp;</b>","m":1.50}`,
+			pretty:   true,
+			expected: "{\n  \"z\": 1,\n  \"a\": \"<b>&amp;</b>\",\n  \"m\": 1.50\n}",
+		},
+		{
+			name:     "pretty reuses detected indent and trailing newline",
+			input:    "{\n\t\"a\": [1, {\"b\": null}]\n}\n",
+			pretty:   true,
+			expected: "{\n\t\"a\": [\n\t\t1,\n\t\t{\n\t\t\t\"b\": null\n\t\t}\n\t]\n}\n",
+		},
+		{
+			name:     "compact",
+			input:    "{\n    \"a\": [],\n    \"b\": {}\n}",
+			compact:  true,
+			expected: `{"a":[],"b":{}}`,
+		},
+		{
+			name:     "unchanged when neither is set",
+			input:    `{ "a" : 1 }`,
+			expected: `{ "a" : 1 }`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format([]byte(tt.input), tt.pretty, tt.compact)
+			if err != nil {
+				t.Fatalf("Format() error = %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("Format() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFormatWithOptions(t *testing.T) {
+	input := `{"b":"<é>","a":"😀"}`
+
+	got, err := FormatWith([]byte(input), FormatOptions{SortKeys: true, EscapeHTML: true, ASCII: true})
+	if err != nil {
+		t.Fatalf("FormatWith() error = %v", err)
+	}
+	want := `{"a":"\ud83d\ude00","b":"\u003c\u00e9\u003e"}`
+	if string(got) != want {
+		t.Errorf("FormatWith() = %s, want %s", got, want)
+	}
+}
+
+func TestDetectIndent(t *testing.T) {
+	tests := map[string]string{
+		"{\"a\":1}":             "",
+		"{\n  \"a\": 1\n}":      "  ",
+		"{\n    \"a\": 1\n}":    "    ",
+		"{\n\t\"a\": 1\n}":      "\t",
+		"{\n\n  \"a\": {\n}\n}": "  ",
+	}
+	for input, want := range tests {
+		if got := DetectIndent([]byte(input)); got != want {
+			t.Errorf("DetectIndent(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -1,59 +1,101 @@
 package json
 
 import (
-	"encoding/json"
 	"io"
 	"os"
 )
 
-// ReadFile reads JSON from a file
+// ReadFile reads JSON from a file, converting other formats based on the file extension
 func ReadFile(path string) ([]byte, error) {
+	return ReadFileAs(path, FormatAuto)
+}
+
+// ReadFileAs reads a document in the given format and returns it as JSON
+func ReadFileAs(path string, format FileFormat) ([]byte, error) {
+	var data []byte
+	var err error
 	if path == "-" {
-		return io.ReadAll(os.Stdin)
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
 	}
-	return os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Decode(data, ResolveFormat(path, format))
 }
 
-// WriteFile writes JSON to a file atomically
+// Decode converts a document in the given format to JSON.
+func Decode(data []byte, format FileFormat) ([]byte, error) {
+	if c, ok := codecs[format]; ok {
+		return c.Decode(data)
+	}
+	return data, nil
+}
+
+// Encode converts JSON to the given format. template holds the document the
+// JSON was read from or will replace, in the same format, so its comments and
+// layout are kept; it may be nil.
+func Encode(data, template []byte, format FileFormat) ([]byte, error) {
+	if c, ok := codecs[format]; ok {
+		return c.Encode(data, template)
+	}
+	return data, nil
+}
+
+// WriteFile writes JSON to a file atomically, converting to the format implied by the file extension
 func WriteFile(path string, data []byte, perm os.FileMode) error {
-	if path == "-" {
-		_, err := os.Stdout.Write(data)
+	return WriteFileAs(path, data, perm, FormatAuto)
+}
+
+// WriteFileAs converts JSON to the given format and writes it to a file atomically.
+// An existing destination is used as a template so its comments and layout are kept.
+func WriteFileAs(path string, data []byte, perm os.FileMode, format FileFormat) error {
+	data, err := encodeFile(path, data, format)
+	if err != nil {
 		return err
 	}
 
-	// Write to temp file first
-	tempFile := path + ".tmp"
-	if err := os.WriteFile(tempFile, data, perm); err != nil {
+	if path == "-" {
+		_, err := os.Stdout.Write(data)
 		return err
 	}
 
-	// Rename atomically
-	return os.Rename(tempFile, path)
+	return AtomicWriteFile(path, data, perm, WriteOptions{})
 }
 
-// Validate checks if data is valid JSON
+// encodeFile converts JSON to the format of path, using the existing file
+// as a template.
+func encodeFile(path string, data []byte, format FileFormat) ([]byte, error) {
+	var original []byte
+	if path != "-" {
+		original, _ = os.ReadFile(path)
+	}
+	return Encode(data, original, ResolveFormat(path, format))
+}
+
+// Validate checks if data is valid JSON, returning a *SyntaxError with the line and column of any problem
 func Validate(data []byte) error {
-	var v interface{}
-	return json.Unmarshal(data, &v)
+	return ValidateFile(data, "")
 }
 
-// Format formats JSON with optional pretty printing
+// Format formats JSON with optional pretty printing. Key order, numbers and
+// string escapes are preserved; pretty printing reuses the document's own
+// indentation and trailing newline when it has them.
 func Format(data []byte, pretty bool, compact bool) ([]byte, error) {
 	if !pretty && !compact {
 		return data, nil
 	}
 
-	var v interface{}
-	if err := json.Unmarshal(data, &v); err != nil {
-		return nil, err
-	}
-
-	if compact {
-		return json.Marshal(v)
+	opts := FormatOptions{TrailingNewline: HasTrailingNewline(data)}
+	if !compact {
+		opts.Indent = DetectIndent(data)
+		if opts.Indent == "" {
+			opts.Indent = DefaultIndent
+		}
 	}
-
-	// Pretty print
-	return json.MarshalIndent(v, "", "  ")
+	return FormatWith(data, opts)
 }
 
 // GetFileInfo gets file permissions
@@ -0,0 +1,71 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// pathMetaChars are the characters gjson and sjson give special meaning in a
+// path: separators, wildcards, array queries, modifiers, literals and
+// multipaths.
+const pathMetaChars = `\.*?|#@!=<>%[]{}`
+
+// EscapePathKey escapes key so it can be used as a single gjson/sjson path
+// segment, whatever characters it contains.
+func EscapePathKey(key string) string {
+	if !strings.ContainsAny(key, pathMetaChars) {
+		return key
+	}
+	var b strings.Builder
+	for _, r := range key {
+		if strings.ContainsRune(pathMetaChars, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// JoinPath appends keys to a gjson path, escaping each as one segment.
+func JoinPath(base string, keys ...string) string {
+	for _, key := range keys {
+		key = EscapePathKey(key)
+		if base == "" {
+			base = key
+		} else {
+			base += "." + key
+		}
+	}
+	return base
+}
+
+// QuoteString encodes s as a JSON string literal without HTML escaping.
+func QuoteString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(s); err != nil {
+		return strconv.Quote(s)
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// EqualJSON reports whether two JSON texts hold the same value, ignoring
+// whitespace and differences in how strings are escaped. Numbers are
+// compared as written, so 1 and 1.0 differ.
+func EqualJSON(a, b []byte) bool {
+	ra, rb := gjson.ParseBytes(a), gjson.ParseBytes(b)
+	if ra.Type == gjson.String || rb.Type == gjson.String {
+		return ra.Type == rb.Type && ra.Str == rb.Str
+	}
+
+	var ca, cb bytes.Buffer
+	if json.Compact(&ca, a) != nil || json.Compact(&cb, b) != nil {
+		return false
+	}
+	return bytes.Equal(ca.Bytes(), cb.Bytes())
+}
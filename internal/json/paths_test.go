@@ -0,0 +1,44 @@
+package json
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestEscapePathKey(t *testing.T) {
+	keys := []string{"plain", "a.b", "a|b", "@tag", "#", "!x", "a=b", `back\slash`, "[0]", "{k}", "50%", "a*?", "<>"}
+	for _, key := range keys {
+		path := JoinPath("root", key)
+		doc, err := sjson.Set(`{"root":{}}`, path, 1)
+		if err != nil {
+			t.Fatalf("sjson.Set(%q) error = %v", path, err)
+		}
+		if got := gjson.Get(doc, "root").Map(); len(got) != 1 || !got[key].Exists() {
+			t.Errorf("key %q via path %q produced %s", key, path, doc)
+		}
+		if !gjson.Get(doc, path).Exists() {
+			t.Errorf("gjson.Get(%q) found nothing in %s", path, doc)
+		}
+	}
+}
+
+func TestEqualJSON(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{`{"a": [1, 2]}`, `{"a":[1,2]}`, true},
+		{`"\u0041"`, `"A"`, true},
+		{`"1"`, `1`, false},
+		{`1`, `1.0`, false},
+		{`{"a":1,"b":2}`, `{"b":2,"a":1}`, false},
+		{`{bad`, `{bad`, false},
+	}
+	for _, tt := range tests {
+		if got := EqualJSON([]byte(tt.a), []byte(tt.b)); got != tt.want {
+			t.Errorf("EqualJSON(%s, %s) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
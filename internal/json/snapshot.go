@@ -0,0 +1,83 @@
+package json
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Snapshot records the state of a file when it was read, so a later write
+// can detect that someone else changed it in the meantime.
+type Snapshot struct {
+	Exists  bool
+	Size    int64
+	ModTime time.Time
+	Hash    [sha256.Size]byte
+}
+
+// ModifiedError reports a file that changed after it was read.
+type ModifiedError struct {
+	Path string
+}
+
+func (e *ModifiedError) Error() string {
+	return fmt.Sprintf("%s was modified by another process since it was read", e.Path)
+}
+
+// TakeSnapshot records the current state of the file at path. A missing file
+// yields a snapshot with Exists unset.
+func TakeSnapshot(path string) (*Snapshot, error) {
+	_, snapshot, err := ReadSnapshot(path)
+	if os.IsNotExist(err) {
+		return &Snapshot{}, nil
+	}
+	return snapshot, err
+}
+
+// ReadSnapshot reads the file at path and returns its contents with a
+// snapshot of them. The file is examined before it is read, so a change made
+// while reading shows up as a modification rather than being missed.
+func ReadSnapshot(path string) ([]byte, *Snapshot, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, &Snapshot{Exists: true, Size: info.Size(), ModTime: info.ModTime(), Hash: sha256.Sum256(data)}, nil
+}
+
+// Verify returns a *ModifiedError if the file at path no longer matches the
+// snapshot. The modification time and size are compared first, and the
+// contents are hashed only when those differ, so touching a file without
+// changing it is not a conflict.
+func (s *Snapshot) Verify(path string) error {
+	current, err := os.Stat(path)
+	switch {
+	case os.IsNotExist(err):
+		if s.Exists {
+			return &ModifiedError{Path: path}
+		}
+		return nil
+	case err != nil:
+		return err
+	case !s.Exists:
+		return &ModifiedError{Path: path}
+	case current.Size() == s.Size && current.ModTime().Equal(s.ModTime):
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	hash := sha256.Sum256(data)
+	if !bytes.Equal(hash[:], s.Hash[:]) {
+		return &ModifiedError{Path: path}
+	}
+	return nil
+}
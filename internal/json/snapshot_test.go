@@ -0,0 +1,83 @@
+package json
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshotVerify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := os.WriteFile(path, []byte(`{"n":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	snapshot, err := TakeSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Touching without changing contents is not a conflict
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshot.Verify(path); err != nil {
+		t.Errorf("Verify() after touch = %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"n":2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var modified *ModifiedError
+	if err := snapshot.Verify(path); !errors.As(err, &modified) {
+		t.Errorf("Verify() after change = %v, want *ModifiedError", err)
+	}
+
+	err = AtomicWriteFile(path, []byte(`{"n":3}`), 0644, WriteOptions{Expect: snapshot})
+	if !errors.As(err, &modified) {
+		t.Errorf("AtomicWriteFile() = %v, want *ModifiedError", err)
+	}
+	if got, _ := os.ReadFile(path); string(got) != `{"n":2}` {
+		t.Errorf("concurrent change was clobbered: %s", got)
+	}
+}
+
+func TestSnapshotMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.json")
+	snapshot, err := TakeSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshot.Verify(path); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+	if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := snapshot.Verify(path); err == nil {
+		t.Error("Verify() should report a file created since the snapshot")
+	}
+}
+
+func TestReadSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	if _, _, err := ReadSnapshot(path); !os.IsNotExist(err) {
+		t.Errorf("ReadSnapshot() of a missing file error = %v, want not exist", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"n":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, snapshot, err := ReadSnapshot(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"n":1}` || !snapshot.Exists || snapshot.Size != int64(len(data)) {
+		t.Errorf("ReadSnapshot() = %s, %+v", data, snapshot)
+	}
+	if err := snapshot.Verify(path); err != nil {
+		t.Errorf("Verify() = %v", err)
+	}
+}
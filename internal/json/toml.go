@@ -0,0 +1,757 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+	"github.com/tidwall/gjson"
+)
+
+// tomlCodec converts TOML documents to and from JSON. Edits are patched into
+// the original text where possible so comments and formatting are untouched;
+// structural changes that cannot be patched fall back to re-emitting the
+// whole document in its original key order.
+type tomlCodec struct{}
+
+var bareKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// tomlNode is an ordered tree of the decoded document.
+type tomlNode struct {
+	table  bool
+	array  bool
+	keys   []string
+	fields map[string]*tomlNode
+	items  []*tomlNode
+	raw    string // JSON for scalars
+	inline bool   // Defined inline as a value, not by a [table] header
+}
+
+// tomlSpan locates a key/value expression in the original text.
+type tomlSpan struct {
+	start, end         int // Value bytes
+	lineStart, lineEnd int // Whole line including the trailing newline
+	kind               unstable.Kind
+}
+
+// tomlLayout records where values and tables live in the original text.
+type tomlLayout struct {
+	values map[string]tomlSpan
+	tables map[string]int // Offset after the last line of each [table]
+	kinds  map[string]unstable.Kind
+
+	// rootValues is set when the root table has key/values of its own. If
+	// not, new root keys go just before the first [table].
+	rootValues bool
+}
+
+type tomlEdit struct {
+	start, end int
+	text       string
+	tail       bool // Goes after any other edit at the same offset
+}
+
+func newTOMLTable() *tomlNode {
+	return &tomlNode{table: true, fields: map[string]*tomlNode{}}
+}
+
+func (n *tomlNode) set(key string, child *tomlNode) {
+	if _, ok := n.fields[key]; !ok {
+		n.keys = append(n.keys, key)
+	}
+	n.fields[key] = child
+}
+
+// child returns the table stored under key, creating it if needed. For
+// arrays of tables the most recent element is returned.
+func (n *tomlNode) child(key string) (*tomlNode, error) {
+	existing, ok := n.fields[key]
+	if !ok {
+		existing = newTOMLTable()
+		n.set(key, existing)
+	}
+	if existing.array && len(existing.items) > 0 {
+		existing = existing.items[len(existing.items)-1]
+	}
+	if !existing.table {
+		return nil, fmt.Errorf("key %q is not a table", key)
+	}
+	return existing, nil
+}
+
+func (tomlCodec) Decode(data []byte) ([]byte, error) {
+	root, _, err := parseTOML(data)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	root.writeJSON(&buf)
+	return buf.Bytes(), nil
+}
+
+func (tomlCodec) Encode(data, original []byte) ([]byte, error) {
+	value := gjson.ParseBytes(data)
+	if !value.IsObject() {
+		return nil, fmt.Errorf("TOML documents must be tables")
+	}
+
+	var kinds map[string]unstable.Kind
+	if len(original) > 0 {
+		if root, layout, err := parseTOML(original); err == nil {
+			if patched, ok := patchTOML(original, root, layout, value); ok {
+				return patched, nil
+			}
+			kinds = layout.kinds
+		}
+	}
+
+	var b strings.Builder
+	if err := emitTOMLTable(&b, nil, "", value, kinds); err != nil {
+		return nil, err
+	}
+	return []byte(strings.TrimPrefix(b.String(), "\n")), nil
+}
+
+// parseTOML builds the document tree and records the layout of its expressions.
+func parseTOML(data []byte) (*tomlNode, *tomlLayout, error) {
+	root := newTOMLTable()
+	layout := &tomlLayout{
+		values: map[string]tomlSpan{},
+		tables: map[string]int{"": len(data)},
+		kinds:  map[string]unstable.Kind{},
+	}
+	headers := false
+
+	p := unstable.Parser{KeepComments: false}
+	p.Reset(data)
+
+	current, currentPath := root, ""
+	for p.NextExpression() {
+		expr := p.Expression()
+		var err error
+		if (expr.Kind == unstable.Table || expr.Kind == unstable.ArrayTable) && !headers {
+			headers = true
+			if !layout.rootValues {
+				layout.tables[""] = headerStart(data, int(lastKey(expr).Raw.Offset))
+			}
+		}
+		switch expr.Kind {
+		case unstable.Table:
+			current, currentPath, err = openTOMLTable(root, keyParts(expr.Key()), false)
+			layout.tables[currentPath] = lineEnd(data, int(lastKey(expr).Raw.Offset))
+		case unstable.ArrayTable:
+			current, currentPath, err = openTOMLTable(root, keyParts(expr.Key()), true)
+			layout.tables[currentPath] = lineEnd(data, int(lastKey(expr).Raw.Offset))
+		case unstable.KeyValue:
+			var path string
+			path, err = setTOMLValue(&p, current, currentPath, expr, layout)
+			if err == nil {
+				span := layout.values[path]
+				layout.tables[currentPath] = span.lineEnd
+				layout.rootValues = layout.rootValues || currentPath == ""
+			}
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid TOML: %w", err)
+		}
+	}
+	if err := p.Error(); err != nil {
+		return nil, nil, fmt.Errorf("invalid TOML: %w", err)
+	}
+	return root, layout, nil
+}
+
+func openTOMLTable(root *tomlNode, keys []string, arrayTable bool) (*tomlNode, string, error) {
+	node := root
+	var path []string
+	for i, key := range keys {
+		last := i == len(keys)-1
+		if last && arrayTable {
+			existing, ok := node.fields[key]
+			if !ok {
+				existing = &tomlNode{array: true}
+				node.set(key, existing)
+			}
+			if !existing.array || existing.inline {
+				return nil, "", fmt.Errorf("key %q is not an array of tables", key)
+			}
+			table := newTOMLTable()
+			existing.items = append(existing.items, table)
+			path = append(path, key, fmt.Sprint(len(existing.items)-1))
+			return table, JoinPath("", path...), nil
+		}
+
+		child, err := node.child(key)
+		if err != nil {
+			return nil, "", err
+		}
+		path = append(path, key)
+		if existing := node.fields[key]; existing.array {
+			path = append(path, fmt.Sprint(len(existing.items)-1))
+		}
+		node = child
+	}
+	return node, JoinPath("", path...), nil
+}
+
+func setTOMLValue(p *unstable.Parser, table *tomlNode, tablePath string, expr *unstable.Node, layout *tomlLayout) (string, error) {
+	keys := keyParts(expr.Key())
+	node, path := table, tablePath
+	for _, key := range keys[:len(keys)-1] {
+		child, err := node.child(key)
+		if err != nil {
+			return "", err
+		}
+		node, path = child, JoinPath(path, key)
+	}
+
+	last := keys[len(keys)-1]
+	path = JoinPath(path, last)
+	value, err := tomlValue(p, expr.Value(), path, layout)
+	if err != nil {
+		return "", err
+	}
+	node.set(last, value)
+
+	data := p.Data()
+	start, end := valueRange(p, expr)
+	key := expr.Key()
+	keyStart := int(key.Node().Raw.Offset)
+	layout.values[path] = tomlSpan{
+		start:     start,
+		end:       end,
+		lineStart: bytes.LastIndexByte(data[:keyStart], '\n') + 1,
+		lineEnd:   lineEnd(data, end),
+		kind:      expr.Value().Kind,
+	}
+	return path, nil
+}
+
+func tomlValue(p *unstable.Parser, value *unstable.Node, path string, layout *tomlLayout) (*tomlNode, error) {
+	layout.kinds[path] = value.Kind
+
+	switch value.Kind {
+	case unstable.Array:
+		node := &tomlNode{array: true, inline: true}
+		it := value.Children()
+		for i := 0; it.Next(); i++ {
+			item, err := tomlValue(p, it.Node(), JoinPath(path, fmt.Sprint(i)), layout)
+			if err != nil {
+				return nil, err
+			}
+			node.items = append(node.items, item)
+		}
+		return node, nil
+	case unstable.InlineTable:
+		node := newTOMLTable()
+		node.inline = true
+		it := value.Children()
+		for it.Next() {
+			kv := it.Node()
+			keys := keyParts(kv.Key())
+			target, targetPath := node, path
+			for _, key := range keys[:len(keys)-1] {
+				child, err := target.child(key)
+				if err != nil {
+					return nil, err
+				}
+				target, targetPath = child, JoinPath(targetPath, key)
+			}
+			last := keys[len(keys)-1]
+			child, err := tomlValue(p, kv.Value(), JoinPath(targetPath, last), layout)
+			if err != nil {
+				return nil, err
+			}
+			target.set(last, child)
+		}
+		return node, nil
+	}
+
+	raw, err := tomlScalarToJSON(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &tomlNode{raw: raw}, nil
+}
+
+// tomlScalarToJSON converts a scalar to raw JSON. Datetimes become strings as
+// written; decimal numbers are kept verbatim.
+func tomlScalarToJSON(value *unstable.Node) (string, error) {
+	text := string(value.Data)
+	switch value.Kind {
+	case unstable.Bool:
+		return text, nil
+	case unstable.Integer:
+		clean := strings.TrimPrefix(strings.ReplaceAll(text, "_", ""), "+")
+		n, ok := new(big.Int).SetString(clean, 0)
+		if !ok {
+			return "", fmt.Errorf("invalid integer %q", text)
+		}
+		return n.String(), nil
+	case unstable.Float:
+		clean := strings.TrimPrefix(strings.ReplaceAll(text, "_", ""), "+")
+		if !json.Valid([]byte(clean)) {
+			return "", fmt.Errorf("float %q cannot be represented in JSON", text)
+		}
+		return clean, nil
+	default:
+		return QuoteString(text), nil
+	}
+}
+
+func (n *tomlNode) writeJSON(buf *bytes.Buffer) {
+	switch {
+	case n.table:
+		buf.WriteByte('{')
+		for i, key := range n.keys {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			buf.WriteString(QuoteString(key))
+			buf.WriteByte(':')
+			n.fields[key].writeJSON(buf)
+		}
+		buf.WriteByte('}')
+	case n.array:
+		buf.WriteByte('[')
+		for i, item := range n.items {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			item.writeJSON(buf)
+		}
+		buf.WriteByte(']')
+	default:
+		buf.WriteString(n.raw)
+	}
+}
+
+// patchTOML applies the differences between the original document and value
+// as text edits. It reports false when a change cannot be expressed as a patch.
+func patchTOML(data []byte, root *tomlNode, layout *tomlLayout, value gjson.Result) ([]byte, bool) {
+	var edits []tomlEdit
+	if !diffTOML(&edits, data, "", []string{}, root, value, layout) {
+		return nil, false
+	}
+
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].start != edits[j].start {
+			return edits[i].start < edits[j].start
+		}
+		return !edits[i].tail && edits[j].tail
+	})
+	var out bytes.Buffer
+	pos := 0
+	for _, e := range edits {
+		out.Write(data[pos:e.start])
+		out.WriteString(e.text)
+		pos = e.end
+	}
+	out.Write(data[pos:])
+	return out.Bytes(), true
+}
+
+// diffTOML appends the edits turning old at path into value. keys is the
+// header key path of the table at path, or nil if a [header] there would
+// address a different table, as for all but the last element of an array of
+// tables.
+func diffTOML(edits *[]tomlEdit, data []byte, path string, keys []string, old *tomlNode, value gjson.Result, layout *tomlLayout) bool {
+	var buf bytes.Buffer
+	old.writeJSON(&buf)
+	if EqualJSON(buf.Bytes(), []byte(value.Raw)) {
+		return true
+	}
+
+	if span, ok := layout.values[path]; ok {
+		text, err := tomlInline(value, path, layout.kinds)
+		if err != nil {
+			return false
+		}
+		*edits = append(*edits, tomlEdit{start: span.start, end: span.end, text: text})
+		return true
+	}
+
+	if old.array && value.IsArray() {
+		items := value.Array()
+		if len(items) < len(old.items) {
+			return false
+		}
+		for i := range old.items {
+			itemKeys := keys
+			if i < len(old.items)-1 || len(items) > len(old.items) {
+				itemKeys = nil
+			}
+			if !diffTOML(edits, data, JoinPath(path, fmt.Sprint(i)), itemKeys, old.items[i], items[i], layout) {
+				return false
+			}
+		}
+		if len(items) == len(old.items) {
+			return true
+		}
+
+		// New elements of an array of tables follow the last one
+		if keys == nil || len(old.items) == 0 {
+			return false
+		}
+		var b strings.Builder
+		for i, item := range items[len(old.items):] {
+			if !item.IsObject() {
+				return false
+			}
+			itemPath := JoinPath(path, fmt.Sprint(len(old.items)+i))
+			if err := emitTOMLArrayItem(&b, keys, itemPath, item, layout.kinds); err != nil {
+				return false
+			}
+		}
+		offset := tableEnd(layout, JoinPath(path, fmt.Sprint(len(old.items)-1)))
+		*edits = append(*edits, tomlEdit{start: offset, end: offset, text: appendTOMLText(data, offset, b.String())})
+		return true
+	}
+
+	if !old.table || !value.IsObject() {
+		return false
+	}
+
+	fields := value.Map()
+	for _, key := range old.keys {
+		childPath := JoinPath(path, key)
+		field, ok := fields[key]
+		if !ok {
+			span, isValue := layout.values[childPath]
+			if !isValue {
+				return false
+			}
+			*edits = append(*edits, tomlEdit{start: span.lineStart, end: span.lineEnd})
+			continue
+		}
+		var childKeys []string
+		if keys != nil {
+			childKeys = append(append([]string{}, keys...), key)
+		}
+		if !diffTOML(edits, data, childPath, childKeys, old.fields[key], field, layout) {
+			return false
+		}
+	}
+
+	ok := true
+	value.ForEach(func(key, field gjson.Result) bool {
+		if _, exists := old.fields[key.String()]; exists {
+			return true
+		}
+		offset, isTable := layout.tables[path]
+		if !isTable {
+			ok = false
+			return false
+		}
+		childPath := JoinPath(path, key.String())
+
+		// New tables go at the end of the document under their own header
+		if keys != nil && (field.IsObject() || isArrayOfTables(field)) {
+			// Emitting the parent with only this field writes just its header
+			var b strings.Builder
+			table := gjson.Parse("{" + QuoteString(key.String()) + ":" + field.Raw + "}")
+			if err := emitTOMLTable(&b, keys, path, table, layout.kinds); err != nil {
+				ok = false
+				return false
+			}
+			*edits = append(*edits, tomlEdit{start: len(data), end: len(data), text: appendTOMLText(data, len(data), b.String()), tail: true})
+			return true
+		}
+
+		text, err := tomlInline(field, childPath, layout.kinds)
+		if err != nil {
+			ok = false
+			return false
+		}
+		line := tomlKey(key.String()) + " = " + text + "\n"
+		if path == "" && !layout.rootValues && offset < len(data) {
+			// Keep a blank line between the new key and the first [table]
+			line += "\n"
+		}
+		*edits = append(*edits, tomlEdit{start: offset, end: offset, text: appendTOMLText(data, offset, line)})
+		return true
+	})
+	return ok
+}
+
+// appendTOMLText returns text for insertion at offset, starting it on a new
+// line if offset is not at the start of one.
+func appendTOMLText(data []byte, offset int, text string) string {
+	if offset > 0 && data[offset-1] != '\n' {
+		return "\n" + text
+	}
+	return text
+}
+
+// tableEnd returns the offset after the last line of the table at path,
+// including any sub-tables defined after it.
+func tableEnd(layout *tomlLayout, path string) int {
+	end := layout.tables[path]
+	for p, offset := range layout.tables {
+		if strings.HasPrefix(p, path+".") && offset > end {
+			end = offset
+		}
+	}
+	return end
+}
+
+// headerStart returns where new root keys go in a document whose first
+// expression is the [table] header at offset: before the header and the
+// comment lines directly above it, unless those comments open the document.
+func headerStart(data []byte, offset int) int {
+	start := bytes.LastIndexByte(data[:offset], '\n') + 1
+	header := start
+	for start > 0 {
+		prev := bytes.LastIndexByte(data[:start-1], '\n') + 1
+		if !bytes.HasPrefix(bytes.TrimSpace(data[prev:start]), []byte("#")) {
+			return start
+		}
+		start = prev
+	}
+	return header
+}
+
+// emitTOMLTable writes a table: its plain values first, then sub-tables and
+// arrays of tables, each in document order. Values that were inline in the
+// original document stay inline.
+func emitTOMLTable(b *strings.Builder, keys []string, path string, value gjson.Result, kinds map[string]unstable.Kind) error {
+	var tables, arrayTables []gjson.Result
+	var tableKeys, arrayTableKeys []string
+	var lines strings.Builder
+
+	var err error
+	value.ForEach(func(key, field gjson.Result) bool {
+		childPath := JoinPath(path, key.String())
+		inline := kinds[childPath] == unstable.InlineTable || kinds[childPath] == unstable.Array
+		switch {
+		case field.IsObject() && !inline:
+			tables = append(tables, field)
+			tableKeys = append(tableKeys, key.String())
+		case isArrayOfTables(field) && !inline:
+			arrayTables = append(arrayTables, field)
+			arrayTableKeys = append(arrayTableKeys, key.String())
+		default:
+			var text string
+			text, err = tomlInline(field, childPath, kinds)
+			if err != nil {
+				return false
+			}
+			lines.WriteString(tomlKey(key.String()) + " = " + text + "\n")
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(keys) > 0 && (lines.Len() > 0 || len(tables)+len(arrayTables) == 0) {
+		b.WriteString("\n[" + tomlKeyPath(keys) + "]\n")
+	}
+	b.WriteString(lines.String())
+
+	for i, table := range tables {
+		childKeys := append(append([]string{}, keys...), tableKeys[i])
+		if err := emitTOMLTable(b, childKeys, JoinPath(path, tableKeys[i]), table, kinds); err != nil {
+			return err
+		}
+	}
+	for i, array := range arrayTables {
+		childKeys := append(append([]string{}, keys...), arrayTableKeys[i])
+		for j, item := range array.Array() {
+			itemPath := JoinPath(path, arrayTableKeys[i], fmt.Sprint(j))
+			if err := emitTOMLArrayItem(b, childKeys, itemPath, item, kinds); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// emitTOMLArrayItem writes one element of an array of tables under its
+// [[keys]] header.
+func emitTOMLArrayItem(b *strings.Builder, keys []string, path string, item gjson.Result, kinds map[string]unstable.Kind) error {
+	b.WriteString("\n[[" + tomlKeyPath(keys) + "]]\n")
+	var inner strings.Builder
+	if err := emitTOMLTable(&inner, keys, path, item, kinds); err != nil {
+		return err
+	}
+	// The element's own header was written above.
+	b.WriteString(strings.TrimPrefix(inner.String(), "\n["+tomlKeyPath(keys)+"]\n"))
+	return nil
+}
+
+func isArrayOfTables(value gjson.Result) bool {
+	if !value.IsArray() {
+		return false
+	}
+	items := value.Array()
+	for _, item := range items {
+		if !item.IsObject() {
+			return false
+		}
+	}
+	return len(items) > 0
+}
+
+// tomlInline renders a value in inline form. Strings at paths that held a
+// datetime in the original document are written back as datetimes.
+func tomlInline(value gjson.Result, path string, kinds map[string]unstable.Kind) (string, error) {
+	switch {
+	case value.IsObject():
+		var parts []string
+		var err error
+		value.ForEach(func(key, field gjson.Result) bool {
+			var text string
+			text, err = tomlInline(field, JoinPath(path, key.String()), kinds)
+			parts = append(parts, tomlKey(key.String())+" = "+text)
+			return err == nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case value.IsArray():
+		var parts []string
+		for i, item := range value.Array() {
+			text, err := tomlInline(item, JoinPath(path, fmt.Sprint(i)), kinds)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, text)
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	}
+
+	switch value.Type {
+	case gjson.Null:
+		return "", fmt.Errorf("TOML cannot represent null at %q", path)
+	case gjson.String:
+		if kind, ok := kinds[path]; ok && isTOMLDateTime(value.Str, kind) {
+			return value.Str, nil
+		}
+		return QuoteString(value.Str), nil
+	default:
+		return value.Raw, nil
+	}
+}
+
+// isTOMLDateTime reports whether s parses as a TOML datetime of the given kind.
+func isTOMLDateTime(s string, kind unstable.Kind) bool {
+	switch kind {
+	case unstable.LocalDate, unstable.LocalTime, unstable.LocalDateTime, unstable.DateTime:
+	default:
+		return false
+	}
+	p := unstable.Parser{}
+	p.Reset([]byte("v = " + s))
+	if !p.NextExpression() {
+		return false
+	}
+	value := p.Expression().Value()
+	return value.Kind == kind && string(value.Data) == s
+}
+
+// valueRange returns the byte range of a key/value expression's value in the
+// parser's input. Booleans and datetimes carry no range of their own, and
+// arrays carry none at all, so arrays and inline tables are located from the
+// = after their key and their end is found by scanning.
+func valueRange(p *unstable.Parser, expr *unstable.Node) (start, end int) {
+	value := expr.Value()
+	switch value.Kind {
+	case unstable.Bool, unstable.LocalDate, unstable.LocalTime, unstable.LocalDateTime, unstable.DateTime:
+		r := p.Range(value.Data)
+		return int(r.Offset), int(r.Offset + r.Length)
+	case unstable.Array, unstable.InlineTable:
+		data := p.Data()
+		key := lastKey(expr).Raw
+		start = int(key.Offset + key.Length)
+		for start < len(data) && (data[start] == ' ' || data[start] == '\t' || data[start] == '=') {
+			start++
+		}
+		return start, scanInlineEnd(data, start)
+	}
+	start = int(value.Raw.Offset)
+	return start, start + int(value.Raw.Length)
+}
+
+// scanInlineEnd returns the offset just past the bracket that closes the
+// array or inline table opening at start, skipping strings and comments.
+func scanInlineEnd(data []byte, start int) int {
+	depth := 0
+	for i := start; i < len(data); i++ {
+		switch c := data[i]; c {
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		case '#':
+			for i < len(data) && data[i] != '\n' {
+				i++
+			}
+		case '"', '\'':
+			delim := []byte{c}
+			if bytes.HasPrefix(data[i:], []byte{c, c, c}) {
+				delim = []byte{c, c, c}
+			}
+			i += len(delim)
+			for i < len(data) && !bytes.HasPrefix(data[i:], delim) {
+				if c == '"' && data[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			i += len(delim) - 1
+		}
+	}
+	return len(data)
+}
+
+func lineEnd(data []byte, offset int) int {
+	if i := bytes.IndexByte(data[offset:], '\n'); i >= 0 {
+		return offset + i + 1
+	}
+	return len(data)
+}
+
+func keyParts(it unstable.Iterator) []string {
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Node().Data))
+	}
+	return keys
+}
+
+func lastKey(expr *unstable.Node) *unstable.Node {
+	var last *unstable.Node
+	it := expr.Key()
+	for it.Next() {
+		last = it.Node()
+	}
+	return last
+}
+
+func tomlKey(key string) string {
+	if bareKey.MatchString(key) {
+		return key
+	}
+	return QuoteString(key)
+}
+
+func tomlKeyPath(keys []string) string {
+	parts := make([]string, len(keys))
+	for i, key := range keys {
+		parts[i] = tomlKey(key)
+	}
+	return strings.Join(parts, ".")
+}
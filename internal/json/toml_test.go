@@ -0,0 +1,272 @@
+package json
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/sjson"
+)
+
+const cargoTOML = `# Package manifest
+[package]
+name = "demo"
+version = "0.1.0" # bumped by release tooling
+published = 2024-01-15T10:00:00Z
+
+[dependencies]
+serde = { version = "1.0", features = ["std"] }
+log = "0.4"
+
+[[bin]]
+name = "demo"
+path = "src/main.rs"
+
+[[bin]]
+name = "tool"
+`
+
+func TestTOMLDecode(t *testing.T) {
+	got, err := tomlCodec{}.Decode([]byte(cargoTOML))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	want := `{"package":{"name":"demo","version":"0.1.0","published":"2024-01-15T10:00:00Z"},` +
+		`"dependencies":{"serde":{"version":"1.0","features":["std"]},"log":"0.4"},` +
+		`"bin":[{"name":"demo","path":"src/main.rs"},{"name":"tool"}]}`
+	if string(got) != want {
+		t.Errorf("Decode() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTOMLEncodePatchesInPlace(t *testing.T) {
+	c := tomlCodec{}
+	data, err := c.Decode([]byte(cargoTOML))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	edited := string(data)
+	for _, edit := range []struct {
+		path  string
+		value interface{}
+	}{
+		{"package.version", "1.2.0"},
+		{"package.published", "2024-02-01T08:30:00Z"},
+		{"dependencies.serde.features.-1", "derive"},
+		{"dependencies.tokio", "1"},
+		{"bin.1.path", "src/tool.rs"},
+	} {
+		if edited, err = sjson.Set(edited, edit.path, edit.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := c.Encode([]byte(edited), []byte(cargoTOML))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := `# Package manifest
+[package]
+name = "demo"
+version = "1.2.0" # bumped by release tooling
+published = 2024-02-01T08:30:00Z
+
+[dependencies]
+serde = { version = "1.0", features = ["std", "derive"] }
+log = "0.4"
+tokio = "1"
+
+[[bin]]
+name = "demo"
+path = "src/main.rs"
+
+[[bin]]
+name = "tool"
+path = "src/tool.rs"
+`
+	if string(got) != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestTOMLEncodePatchesStructure(t *testing.T) {
+	original := `# Package manifest
+[package]
+name = "demo" # crate name
+
+[dependencies]
+log = "0.4"
+
+[[bin]]
+name = "demo"
+
+[[bin]]
+name = "tool" # helper
+path = "src/tool.rs"
+`
+	c := tomlCodec{}
+	data, err := c.Decode([]byte(original))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	edited := string(data)
+	for _, edit := range []struct {
+		path  string
+		value string
+	}{
+		{"edition", `"2021"`},
+		{"bin.-1", `{"name":"extra","path":"src/extra.rs"}`},
+		{"dependencies.serde", `{"version":"1.0","features":["derive"]}`},
+	} {
+		if edited, err = sjson.SetRaw(edited, edit.path, edit.value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := c.Encode([]byte(edited), []byte(original))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := `# Package manifest
+edition = "2021"
+
+[package]
+name = "demo" # crate name
+
+[dependencies]
+log = "0.4"
+
+[[bin]]
+name = "demo"
+
+[[bin]]
+name = "tool" # helper
+path = "src/tool.rs"
+
+[[bin]]
+name = "extra"
+path = "src/extra.rs"
+
+[dependencies.serde]
+version = "1.0"
+features = ["derive"]
+`
+	if string(got) != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+
+	decoded, err := c.Decode(got)
+	if err != nil {
+		t.Fatalf("Decode() of encoded output error = %v", err)
+	}
+	var gotValue, wantValue any
+	if err := json.Unmarshal(decoded, &gotValue); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(edited), &wantValue); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("round trip = %s, want %s", decoded, edited)
+	}
+}
+
+func TestTOMLEncodeAppendsToArrays(t *testing.T) {
+	tests := []struct {
+		name     string
+		original string
+		path     string
+		want     string
+	}{
+		{
+			name:     "top-level array",
+			original: "x = 1\narr = [1, 2] # ports\n",
+			path:     "arr.-1",
+			want:     "x = 1\narr = [1, 2, \"derive\"] # ports\n",
+		},
+		{
+			name:     "table array",
+			original: "[package]\nname = \"x\"\n\n[features]\ndefault = [\"std\"]\n",
+			path:     "features.default.-1",
+			want:     "[package]\nname = \"x\"\n\n[features]\ndefault = [\"std\", \"derive\"]\n",
+		},
+		{
+			name:     "multi-line array with comments",
+			original: "# deps\n[features]\n\"quoted key\"=[ # list\n  \"std\", # default\n  \"alloc\",\n]\nother = 1\n",
+			path:     "features.quoted key.-1",
+			want:     "# deps\n[features]\n\"quoted key\"=[\"std\", \"alloc\", \"derive\"]\nother = 1\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tomlCodec{}
+			data, err := c.Decode([]byte(tt.original))
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			edited, err := sjson.Set(string(data), tt.path, "derive")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := c.Encode([]byte(edited), []byte(tt.original))
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", got, tt.want)
+			}
+			decoded, err := c.Decode(got)
+			if err != nil {
+				t.Fatalf("Decode() of encoded output error = %v", err)
+			}
+			if string(decoded) != edited {
+				t.Errorf("round trip = %s, want %s", decoded, edited)
+			}
+		})
+	}
+}
+
+func TestTOMLEncodeNewDocument(t *testing.T) {
+	input := `{"title":"x","owner":{"name":"a"},"servers":[{"ip":"10.0.0.1"},{"ip":"10.0.0.2"}],"ports":[80,443]}`
+	got, err := tomlCodec{}.Encode([]byte(input), nil)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := `title = "x"
+ports = [80, 443]
+
+[owner]
+name = "a"
+
+[[servers]]
+ip = "10.0.0.1"
+
+[[servers]]
+ip = "10.0.0.2"
+`
+	if string(got) != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+
+	decoded, err := tomlCodec{}.Decode(got)
+	if err != nil {
+		t.Fatalf("Decode() of encoded output error = %v", err)
+	}
+	if !strings.Contains(string(decoded), `"servers":[{"ip":"10.0.0.1"},{"ip":"10.0.0.2"}]`) {
+		t.Errorf("round trip lost data: %s", decoded)
+	}
+}
+
+func TestTOMLEncodeRejectsNull(t *testing.T) {
+	if _, err := (tomlCodec{}).Encode([]byte(`{"a":null}`), nil); err == nil {
+		t.Error("Encode() expected error for null value")
+	}
+}
@@ -0,0 +1,52 @@
+//go:build linux
+
+package json
+
+import (
+	"bytes"
+	"errors"
+	"syscall"
+)
+
+// copyXattrs copies the extended attributes of src to dst. File systems
+// without extended attribute support are skipped silently.
+func copyXattrs(src, dst string) error {
+	size, err := syscall.Listxattr(src, nil)
+	if err != nil || size == 0 {
+		return ignoreUnsupported(err)
+	}
+	names := make([]byte, size)
+	if size, err = syscall.Listxattr(src, names); err != nil {
+		return ignoreUnsupported(err)
+	}
+
+	for _, name := range bytes.Split(names[:size], []byte{0}) {
+		if len(name) == 0 {
+			continue
+		}
+		attr := string(name)
+		n, err := syscall.Getxattr(src, attr, nil)
+		if err != nil {
+			return ignoreUnsupported(err)
+		}
+		value := make([]byte, n)
+		if n, err = syscall.Getxattr(src, attr, value); err != nil {
+			return ignoreUnsupported(err)
+		}
+		if err := syscall.Setxattr(dst, attr, value[:n], 0); err != nil {
+			// Unprivileged users cannot set trusted or security attributes
+			if errors.Is(err, syscall.EPERM) {
+				continue
+			}
+			return ignoreUnsupported(err)
+		}
+	}
+	return nil
+}
+
+func ignoreUnsupported(err error) error {
+	if errors.Is(err, syscall.ENOTSUP) {
+		return nil
+	}
+	return err
+}
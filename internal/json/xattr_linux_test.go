@@ -0,0 +1,39 @@
+//go:build linux
+
+package json
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+func TestAtomicWriteFileCopiesXattrs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := syscall.Setxattr(path, "user.je.test", []byte("kept"), 0); err != nil {
+		t.Skipf("extended attributes not supported: %v", err)
+	}
+
+	if err := AtomicWriteFile(path, []byte(`{"a":1}`), 0644, WriteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := syscall.Getxattr(path, "user.je.test", make([]byte, 16)); err == nil {
+		t.Error("attribute copied without WriteOptions.Xattrs")
+	}
+
+	if err := syscall.Setxattr(path, "user.je.test", []byte("kept"), 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := AtomicWriteFile(path, []byte(`{"a":2}`), 0644, WriteOptions{Xattrs: true}); err != nil {
+		t.Fatalf("AtomicWriteFile() error = %v", err)
+	}
+	value := make([]byte, 16)
+	n, err := syscall.Getxattr(path, "user.je.test", value)
+	if err != nil || string(value[:n]) != "kept" {
+		t.Errorf("user.je.test = %q, %v, want kept", value[:n], err)
+	}
+}
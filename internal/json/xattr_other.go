@@ -0,0 +1,8 @@
+//go:build !linux
+
+package json
+
+// copyXattrs is a no-op on platforms without extended attribute support.
+func copyXattrs(string, string) error {
+	return nil
+}
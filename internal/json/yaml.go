@@ -0,0 +1,465 @@
+package json
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"gopkg.in/yaml.v3"
+)
+
+// yamlCodec converts YAML documents to and from JSON. Writes are merged into
+// the previous YAML node tree so comments, key order and scalar styles of
+// untouched values survive the edit.
+type yamlCodec struct{}
+
+var decimalInt = regexp.MustCompile(`^-?(0|[1-9][0-9]*)$`)
+
+func (yamlCodec) Decode(data []byte) ([]byte, error) {
+	doc, err := parseYAMLDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return []byte("{}"), nil
+	}
+
+	var buf bytes.Buffer
+	if err := yamlToJSON(&buf, doc.Content[0]); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (yamlCodec) Encode(data, original []byte) ([]byte, error) {
+	value := gjson.ParseBytes(data)
+
+	doc, err := parseYAMLDocument(original)
+	var multi *MultiDocumentError
+	if errors.As(err, &multi) {
+		// Writing a single document would drop the others
+		return nil, err
+	}
+	if err != nil || len(doc.Content) == 0 {
+		doc = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{newYAMLNode(value)}}
+	} else {
+		doc.Content[0] = mergeYAMLNode(doc.Content[0], value)
+		untagMergeKeys(doc)
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(detectYAMLIndent(original))
+	if err := enc.Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// MultiDocumentError reports a YAML stream holding more than one document.
+// Only single-document files can be edited, since the documents have no
+// single JSON equivalent and writing one back would drop the rest.
+type MultiDocumentError struct {
+	Documents int
+}
+
+func (e *MultiDocumentError) Error() string {
+	return fmt.Sprintf("YAML input has %d documents; only single-document files are supported", e.Documents)
+}
+
+// parseYAMLDocument parses a YAML stream that must hold at most one
+// document. Empty documents, such as one left by a trailing ---, are ignored.
+func parseYAMLDocument(data []byte) (*yaml.Node, error) {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	doc := &yaml.Node{Kind: yaml.DocumentNode}
+	count := 0
+	for {
+		var next yaml.Node
+		err := dec.Decode(&next)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid YAML: %w", err)
+		}
+		if emptyYAMLDocument(&next) {
+			continue
+		}
+		count++
+		doc = &next
+	}
+	if count > 1 {
+		return nil, &MultiDocumentError{Documents: count}
+	}
+	return doc, nil
+}
+
+func emptyYAMLDocument(doc *yaml.Node) bool {
+	if len(doc.Content) == 0 {
+		return true
+	}
+	node := doc.Content[0]
+	return len(doc.Content) == 1 && node.Kind == yaml.ScalarNode && node.ShortTag() == "!!null" &&
+		node.Value == "" && node.HeadComment == "" && node.LineComment == "" && node.FootComment == ""
+}
+
+// yamlToJSON writes the JSON equivalent of a YAML node, keeping mapping key order.
+func yamlToJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return yamlToJSON(buf, node.Content[0])
+	case yaml.AliasNode:
+		return yamlToJSON(buf, node.Alias)
+	case yaml.MappingNode:
+		return yamlMappingToJSON(buf, node)
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, item := range node.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := yamlToJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+		return nil
+	case yaml.ScalarNode:
+		raw, err := yamlScalarToJSON(node)
+		if err != nil {
+			return err
+		}
+		buf.WriteString(raw)
+		return nil
+	default:
+		return fmt.Errorf("unsupported YAML node at line %d", node.Line)
+	}
+}
+
+func yamlMappingToJSON(buf *bytes.Buffer, node *yaml.Node) error {
+	pairs, err := flattenYAMLMapping(node)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteByte('{')
+	for i, pair := range pairs {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.WriteString(QuoteString(pair[0].Value))
+		buf.WriteByte(':')
+		if err := yamlToJSON(buf, pair[1]); err != nil {
+			return err
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+// flattenYAMLMapping returns the key/value pairs of a mapping with merge keys
+// (<<) expanded. Explicit keys take precedence over merged ones.
+func flattenYAMLMapping(node *yaml.Node) ([][2]*yaml.Node, error) {
+	var pairs [][2]*yaml.Node
+	index := map[string]int{}
+	explicit := map[string]bool{}
+
+	add := func(key, value *yaml.Node, isExplicit bool) {
+		if i, ok := index[key.Value]; ok {
+			if isExplicit || !explicit[key.Value] {
+				pairs[i][1] = value
+				explicit[key.Value] = isExplicit
+			}
+			return
+		}
+		index[key.Value] = len(pairs)
+		explicit[key.Value] = isExplicit
+		pairs = append(pairs, [2]*yaml.Node{key, value})
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if key.Kind != yaml.ScalarNode {
+			return nil, fmt.Errorf("unsupported non-scalar YAML key at line %d", key.Line)
+		}
+		if key.ShortTag() != "!!merge" {
+			add(key, value, true)
+			continue
+		}
+		for _, source := range mergeSources(value) {
+			merged, err := flattenYAMLMapping(source)
+			if err != nil {
+				return nil, err
+			}
+			for _, pair := range merged {
+				add(pair[0], pair[1], false)
+			}
+		}
+	}
+	return pairs, nil
+}
+
+func mergeSources(value *yaml.Node) []*yaml.Node {
+	if value.Kind == yaml.AliasNode {
+		value = value.Alias
+	}
+	if value.Kind == yaml.MappingNode {
+		return []*yaml.Node{value}
+	}
+	var sources []*yaml.Node
+	for _, item := range value.Content {
+		sources = append(sources, mergeSources(item)...)
+	}
+	return sources
+}
+
+// yamlScalarToJSON converts a scalar to raw JSON. Decimal numbers are kept as
+// written so large integers and decimals are not rounded.
+func yamlScalarToJSON(node *yaml.Node) (string, error) {
+	switch node.ShortTag() {
+	case "!!null":
+		return "null", nil
+	case "!!bool":
+		var b bool
+		if err := node.Decode(&b); err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	case "!!int":
+		if decimalInt.MatchString(node.Value) {
+			return node.Value, nil
+		}
+		n, ok := new(big.Int).SetString(strings.TrimPrefix(node.Value, "+"), 0)
+		if !ok {
+			return "", fmt.Errorf("invalid YAML integer %q at line %d", node.Value, node.Line)
+		}
+		return n.String(), nil
+	case "!!float":
+		if json.Valid([]byte(node.Value)) {
+			return node.Value, nil
+		}
+		var f float64
+		if err := node.Decode(&f); err != nil {
+			return "", err
+		}
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", fmt.Errorf("YAML value %q at line %d cannot be represented in JSON", node.Value, node.Line)
+		}
+		return strconv.FormatFloat(f, 'g', -1, 64), nil
+	default:
+		return QuoteString(node.Value), nil
+	}
+}
+
+// mergeYAMLNode returns node updated to hold value. Nodes whose value is
+// unchanged are returned as-is; replaced nodes keep the original comments.
+func mergeYAMLNode(node *yaml.Node, value gjson.Result) *yaml.Node {
+	if equalYAMLValue(node, value) {
+		return node
+	}
+
+	switch {
+	case node.Kind == yaml.MappingNode && value.IsObject():
+		return mergeYAMLMapping(node, value)
+	case node.Kind == yaml.SequenceNode && value.IsArray():
+		return mergeYAMLSequence(node, value)
+	}
+
+	replacement := newYAMLNode(value)
+	replacement.HeadComment = node.HeadComment
+	replacement.LineComment = node.LineComment
+	replacement.FootComment = node.FootComment
+	if node.Kind == yaml.ScalarNode && replacement.Kind == yaml.ScalarNode &&
+		node.ShortTag() == "!!str" && replacement.Tag == "!!str" {
+		replacement.Style = node.Style
+	}
+	return replacement
+}
+
+func mergeYAMLMapping(node *yaml.Node, value gjson.Result) *yaml.Node {
+	fields := value.Map()
+	seen := map[string]bool{}
+	var content []*yaml.Node
+
+	// Merge keys (<<) are kept as long as every inherited field survives the
+	// edit; inherited fields whose value is unchanged are not written out.
+	inherited := inheritedYAMLFields(node)
+	keepMerge := true
+	for key := range inherited {
+		if _, ok := fields[key]; !ok {
+			keepMerge = false
+			break
+		}
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, child := node.Content[i], node.Content[i+1]
+		if key.ShortTag() == "!!merge" {
+			if keepMerge {
+				content = append(content, key, child)
+			}
+			continue
+		}
+		field, ok := fields[key.Value]
+		if !ok || seen[key.Value] {
+			continue
+		}
+		seen[key.Value] = true
+		content = append(content, key, mergeYAMLNode(child, field))
+	}
+
+	value.ForEach(func(key, field gjson.Result) bool {
+		if seen[key.String()] {
+			return true
+		}
+		if source, ok := inherited[key.String()]; ok && keepMerge && equalYAMLValue(source, field) {
+			return true
+		}
+		content = append(content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.String()}, newYAMLNode(field))
+		return true
+	})
+
+	node.Content = content
+	return node
+}
+
+// untagMergeKeys clears the resolved tag of plain << keys, which the encoder
+// would otherwise print as "!!merge <<". The key resolves to a merge again
+// when read back.
+func untagMergeKeys(node *yaml.Node) {
+	if node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := node.Content[i]; key.Tag == "!!merge" && key.Style == 0 {
+				key.Tag = ""
+			}
+		}
+	}
+	for _, child := range node.Content {
+		untagMergeKeys(child)
+	}
+}
+
+// inheritedYAMLFields returns the fields a mapping receives through merge
+// keys and does not override explicitly.
+func inheritedYAMLFields(node *yaml.Node) map[string]*yaml.Node {
+	merges := &yaml.Node{Kind: yaml.MappingNode}
+	explicit := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if key.ShortTag() == "!!merge" {
+			merges.Content = append(merges.Content, key, node.Content[i+1])
+		} else {
+			explicit[key.Value] = true
+		}
+	}
+	if len(merges.Content) == 0 {
+		return nil
+	}
+
+	pairs, err := flattenYAMLMapping(merges)
+	if err != nil {
+		return nil
+	}
+	fields := map[string]*yaml.Node{}
+	for _, pair := range pairs {
+		if !explicit[pair[0].Value] {
+			fields[pair[0].Value] = pair[1]
+		}
+	}
+	return fields
+}
+
+func mergeYAMLSequence(node *yaml.Node, value gjson.Result) *yaml.Node {
+	items := value.Array()
+	content := make([]*yaml.Node, 0, len(items))
+	for i, item := range items {
+		if i < len(node.Content) {
+			content = append(content, mergeYAMLNode(node.Content[i], item))
+		} else {
+			content = append(content, newYAMLNode(item))
+		}
+	}
+	node.Content = content
+	return node
+}
+
+// equalYAMLValue reports whether node already encodes value.
+func equalYAMLValue(node *yaml.Node, value gjson.Result) bool {
+	var buf bytes.Buffer
+	if err := yamlToJSON(&buf, node); err != nil {
+		return false
+	}
+	var a, b bytes.Buffer
+	if json.Compact(&a, buf.Bytes()) != nil || json.Compact(&b, []byte(value.Raw)) != nil {
+		return false
+	}
+	return bytes.Equal(a.Bytes(), b.Bytes())
+}
+
+// newYAMLNode builds a YAML node tree for a JSON value.
+func newYAMLNode(value gjson.Result) *yaml.Node {
+	switch {
+	case value.IsObject():
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		value.ForEach(func(key, field gjson.Result) bool {
+			node.Content = append(node.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key.String()},
+				newYAMLNode(field))
+			return true
+		})
+		return node
+	case value.IsArray():
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range value.Array() {
+			node.Content = append(node.Content, newYAMLNode(item))
+		}
+		return node
+	}
+
+	switch value.Type {
+	case gjson.String:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.Str}
+	case gjson.Number:
+		tag := "!!float"
+		if decimalInt.MatchString(value.Raw) {
+			tag = "!!int"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: value.Raw}
+	case gjson.True, gjson.False:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: value.Raw}
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+	}
+}
+
+// detectYAMLIndent returns the indentation width used by a YAML document,
+// defaulting to two spaces.
+func detectYAMLIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if indent == 0 || trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "- ") && indent < 2 {
+			continue
+		}
+		return indent
+	}
+	return 2
+}
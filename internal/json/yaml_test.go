@@ -0,0 +1,224 @@
+package json
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/sjson"
+)
+
+func TestYAMLDecode(t *testing.T) {
+	input := `# service config
+name: api
+port: 8080
+id: 12345678901234567890
+ratio: 1.50
+mask: 0x1F
+enabled: true
+empty: null
+tags: [a, b]
+base: &base
+  host: localhost
+prod:
+  <<: *base
+  port: 443
+`
+	got, err := yamlCodec{}.Decode([]byte(input))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	want := `{"name":"api","port":8080,"id":12345678901234567890,"ratio":1.50,"mask":31,` +
+		`"enabled":true,"empty":null,"tags":["a","b"],"base":{"host":"localhost"},` +
+		`"prod":{"host":"localhost","port":443}}`
+	if string(got) != want {
+		t.Errorf("Decode() = %s, want %s", got, want)
+	}
+}
+
+func TestYAMLEncodePreservesComments(t *testing.T) {
+	original := `# service config
+name: api # the service name
+server:
+  host: localhost
+  # listening port
+  port: 8080
+`
+	c := yamlCodec{}
+	data, err := c.Decode([]byte(original))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	edited, err := sjson.Set(string(data), "server.port", 9090)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited, err = sjson.Set(edited, "server.tls", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.Encode([]byte(edited), []byte(original))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	want := `# service config
+name: api # the service name
+server:
+  host: localhost
+  # listening port
+  port: 9090
+  tls: true
+`
+	if string(got) != want {
+		t.Errorf("Encode() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestYAMLEncodePreservesMergeKeys(t *testing.T) {
+	original := `base: &base
+  host: localhost
+  port: 80
+prod:
+  <<: *base
+  port: 443
+`
+	c := yamlCodec{}
+	data, err := c.Decode([]byte(original))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		path  string
+		value any
+		want  string
+	}{
+		{"explicit field", "prod.port", 444, `base: &base
+  host: localhost
+  port: 80
+prod:
+  <<: *base
+  port: 444
+`},
+		{"override inherited field", "prod.host", "example.com", `base: &base
+  host: localhost
+  port: 80
+prod:
+  <<: *base
+  port: 443
+  host: example.com
+`},
+		{"new field", "prod.tls", true, `base: &base
+  host: localhost
+  port: 80
+prod:
+  <<: *base
+  port: 443
+  tls: true
+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edited, err := sjson.Set(string(data), tt.path, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := c.Encode([]byte(edited), []byte(original))
+			if err != nil {
+				t.Fatalf("Encode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Encode() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+
+	// Deleting an inherited field cannot be expressed with <<, so the merge
+	// is inlined instead.
+	edited, err := sjson.Delete(string(data), "prod.host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.Encode([]byte(edited), []byte(original))
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	roundTrip, err := c.Decode(got)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if !EqualJSON(roundTrip, []byte(edited)) {
+		t.Errorf("Encode() after delete = %s, want %s", roundTrip, edited)
+	}
+}
+
+func TestYAMLEncodeQuotesAmbiguousStrings(t *testing.T) {
+	got, err := yamlCodec{}.Encode([]byte(`{"version":"1.0","flag":"true","n":1.0}`), nil)
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+	if !strings.Contains(string(got), `version: "1.0"`) || !strings.Contains(string(got), `flag: "true"`) ||
+		!strings.Contains(string(got), "n: 1.0") {
+		t.Errorf("Encode() = %s, expected ambiguous strings to be quoted", got)
+	}
+}
+
+func TestYAMLMultiDocument(t *testing.T) {
+	c := yamlCodec{}
+	multi := []byte("kind: A\n---\nkind: B\n")
+
+	var docErr *MultiDocumentError
+	if _, err := c.Decode(multi); !errors.As(err, &docErr) || docErr.Documents != 2 {
+		t.Errorf("Decode() error = %v, want MultiDocumentError with 2 documents", err)
+	}
+	if _, err := c.Encode([]byte(`{"kind":"A"}`), multi); !errors.As(err, &docErr) {
+		t.Errorf("Encode() error = %v, want MultiDocumentError", err)
+	}
+
+	got, err := c.Decode([]byte("---\nkind: A\n---\n"))
+	if err != nil {
+		t.Fatalf("Decode() with trailing separator error = %v", err)
+	}
+	if string(got) != `{"kind":"A"}` {
+		t.Errorf("Decode() = %s, want {\"kind\":\"A\"}", got)
+	}
+}
+
+func TestDetectFormat(t *testing.T) {
+	tests := map[string]FileFormat{
+		"config.json": FormatJSON,
+		"config.yaml": FormatYAML,
+		"config.YML":  FormatYAML,
+		"config":      FormatJSON,
+	}
+	for path, want := range tests {
+		if got := DetectFormat(path); got != want {
+			t.Errorf("DetectFormat(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestOutputFormat(t *testing.T) {
+	tests := []struct {
+		source, dest string
+		format       FileFormat
+		want         FileFormat
+	}{
+		{"config.yaml", "-", FormatAuto, FormatYAML},
+		{"config.yaml", "out", FormatAuto, FormatYAML},
+		{"config.yaml", "out.json", FormatAuto, FormatJSON},
+		{"config.json", "out.toml", FormatAuto, FormatTOML},
+		{"-", "-", FormatAuto, FormatJSON},
+		{"config.yaml", "out.json", FormatTOML, FormatTOML},
+	}
+	for _, tt := range tests {
+		if got := OutputFormat(tt.source, tt.dest, tt.format); got != tt.want {
+			t.Errorf("OutputFormat(%q, %q, %v) = %v, want %v", tt.source, tt.dest, tt.format, got, tt.want)
+		}
+	}
+}
@@ -23,10 +23,11 @@ func parseArrayMapPath(path string) (basePath, property string, err error) {
 }
 
 // validateArrayPath ensures the path exists and is an array.
+// A missing path is reported as a *PathNotFoundError with suggestions.
 func validateArrayPath(jsonStr, basePath string) error {
 	result := gjson.Get(jsonStr, basePath)
 	if !result.Exists() {
-		return fmt.Errorf("array path %q does not exist", basePath)
+		return newPathNotFoundError(jsonStr, basePath)
 	}
 	if !result.IsArray() {
 		return fmt.Errorf("path %q is not an array", basePath)
@@ -34,15 +35,25 @@ func validateArrayPath(jsonStr, basePath string) error {
 	return nil
 }
 
-// applyToArrayElements sets a property on each element of an array.
-func applyToArrayElements(jsonStr, basePath, property string, value interface{}) (string, error) {
+// applyToArrayElements sets a property to a raw JSON value on each element of an array.
+// With StrictPaths the property must already exist on every element.
+func applyToArrayElements(jsonStr, basePath, property, value string, opts Options) (string, error) {
 	result := gjson.Get(jsonStr, basePath)
 	array := result.Array()
 
 	for i := range array {
-		elementPath := fmt.Sprintf("%s.%d.%s", basePath, i, property)
-		var err error
-		jsonStr, err = sjson.Set(jsonStr, elementPath, value)
+		elementPath, err := resolvePath(jsonStr, fmt.Sprintf("%s.%d.%s", basePath, i, property), opts)
+		if err != nil {
+			return "", err
+		}
+		if opts.StrictPaths && !gjson.Get(jsonStr, elementPath).Exists() {
+			return "", newPathNotFoundError(jsonStr, elementPath)
+		}
+		elementValue, err := checkType(jsonStr, elementPath, value, opts)
+		if err != nil {
+			return "", err
+		}
+		jsonStr, err = sjson.SetRaw(jsonStr, elementPath, elementValue)
 		if err != nil {
 			return "", fmt.Errorf("failed to set %s: %w", elementPath, err)
 		}
@@ -13,7 +13,7 @@ func prepareArrayPath(jsonStr, basePath string) (string, error) {
 	result := gjson.Get(jsonStr, basePath)
 	if !result.Exists() {
 		// Create new array
-		return sjson.Set(jsonStr, basePath, []interface{}{})
+		return sjson.SetRaw(jsonStr, basePath, "[]")
 	}
 	if !result.IsArray() {
 		return "", fmt.Errorf("cannot append to non-array at path %q", basePath)
@@ -21,13 +21,13 @@ func prepareArrayPath(jsonStr, basePath string) (string, error) {
 	return jsonStr, nil
 }
 
-// appendToArray adds a value to the end of an array at the given path.
-func appendToArray(jsonStr, basePath string, value interface{}) (string, error) {
+// appendToArray adds a raw JSON value to the end of an array at the given path.
+func appendToArray(jsonStr, basePath, value string) (string, error) {
 	result := gjson.Get(jsonStr, basePath)
 	currentArray := result.Array()
 	newIndex := len(currentArray)
 	newPath := fmt.Sprintf("%s.%d", basePath, newIndex)
 
-	return sjson.Set(jsonStr, newPath, value)
+	return sjson.SetRaw(jsonStr, newPath, value)
 }
 
@@ -178,7 +178,8 @@ func TestInvalidPaths(t *testing.T) {
 			assignments: []parser.Assignment{
 				{Path: "items.10", Operator: parser.OpAssignJSON, Value: "3"},
 			},
-			wantErr: false, // sjson creates intermediate elements
+			wantErr:     true,
+			errContains: "out of range",
 		},
 	}
 
@@ -0,0 +1,104 @@
+package operations
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+)
+
+// Encoding names accepted for embedding and extracting binary data.
+const (
+	EncodingBase64 = "base64"
+	EncodingHex    = "hex"
+)
+
+// encodingPrefixes maps the prefixes of an @ file reference, as in
+// key@b64:cert.der, to their encodings.
+var encodingPrefixes = map[string]string{
+	"b64:":    EncodingBase64,
+	"base64:": EncodingBase64,
+	"hex:":    EncodingHex,
+}
+
+// readFileValue reads the file for an @ assignment and returns the string
+// to store. A b64: or hex: prefix on the file name encodes the contents;
+// without one the contents must be valid UTF-8, since JSON strings cannot
+// hold arbitrary bytes.
+func readFileValue(value string) (string, error) {
+	file, encoding := splitEncoding(value)
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to read file %s: %w", file, err)
+	}
+
+	switch encoding {
+	case EncodingBase64:
+		return base64.StdEncoding.EncodeToString(content), nil
+	case EncodingHex:
+		return hex.EncodeToString(content), nil
+	}
+	if !utf8.Valid(content) {
+		return "", fmt.Errorf("file %s is not valid UTF-8; embed binary files with @b64: or @hex:", file)
+	}
+	return string(content), nil
+}
+
+// splitEncoding separates an encoding prefix from a file name. A file whose
+// name starts with something that looks like a prefix can still be used.
+func splitEncoding(value string) (file, encoding string) {
+	if _, err := os.Stat(value); err == nil {
+		return value, ""
+	}
+	for prefix, encoding := range encodingPrefixes {
+		if rest, ok := strings.CutPrefix(value, prefix); ok {
+			return rest, encoding
+		}
+	}
+	return value, ""
+}
+
+// ExtractValue returns the value at path in data. Strings are returned
+// unquoted and other values as JSON. With decode set to "base64" or "hex"
+// the string is decoded back to the bytes it was embedded from.
+func ExtractValue(data []byte, path, decode string) ([]byte, error) {
+	result := gjson.GetBytes(data, path)
+	if !result.Exists() {
+		return nil, newPathNotFoundError(string(data), path)
+	}
+
+	if decode == "" {
+		if result.Type == gjson.String {
+			return []byte(result.Str), nil
+		}
+		return []byte(result.Raw), nil
+	}
+	if result.Type != gjson.String {
+		return nil, fmt.Errorf("cannot decode %q: value is %s, not a string", path, typeName(result))
+	}
+
+	switch decode {
+	case EncodingBase64, "b64":
+		decoded, err := base64.StdEncoding.DecodeString(result.Str)
+		if err != nil {
+			// Accept unpadded input as produced by some tools
+			decoded, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(result.Str, "="))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode %q as base64: %w", path, err)
+		}
+		return decoded, nil
+	case EncodingHex:
+		decoded, err := hex.DecodeString(result.Str)
+		if err != nil {
+			return nil, fmt.Errorf("cannot decode %q as hex: %w", path, err)
+		}
+		return decoded, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %q: expected base64 or hex", decode)
+	}
+}
@@ -0,0 +1,78 @@
+package operations
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	der := filepath.Join(dir, "cert.der")
+	binary := []byte{0x30, 0x82, 0xff, 0x00, 0xfe, 'a'}
+	if err := os.WriteFile(der, binary, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct{ prefix, decode, stored string }{
+		{"b64:", "base64", `"MIL/AP5h"`},
+		{"hex:", "hex", `"3082ff00fe61"`},
+	} {
+		assignments, err := parser.ParseAssignments([]string{"tls.cert@" + tt.prefix + der})
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := ApplyAssignments([]byte(`{}`), assignments)
+		if err != nil {
+			t.Fatalf("ApplyAssignments() error = %v", err)
+		}
+		if want := `{"tls":{"cert":` + tt.stored + `}}`; string(data) != want {
+			t.Errorf("embedded %s = %s, want %s", tt.decode, data, want)
+		}
+
+		got, err := ExtractValue(data, "tls.cert", tt.decode)
+		if err != nil {
+			t.Fatalf("ExtractValue() error = %v", err)
+		}
+		if !bytes.Equal(got, binary) {
+			t.Errorf("round trip through %s = %x, want %x", tt.decode, got, binary)
+		}
+	}
+
+	// Plain @ refuses bytes a JSON string cannot hold
+	_, err := ApplyAssignments([]byte(`{}`), []parser.Assignment{{Path: "cert", Operator: parser.OpAssignFile, Value: der}})
+	if err == nil || !strings.Contains(err.Error(), "@b64:") {
+		t.Errorf("expected a UTF-8 error suggesting @b64:, got %v", err)
+	}
+}
+
+func TestExtractValue(t *testing.T) {
+	data := []byte(`{"name":"je \"x\"","port":8080,"tags":["a"],"bad":"zz"}`)
+
+	tests := []struct {
+		path, decode, want string
+		wantErr            bool
+	}{
+		{path: "name", want: `je "x"`},
+		{path: "port", want: "8080"},
+		{path: "tags", want: `["a"]`},
+		{path: "missing", wantErr: true},
+		{path: "port", decode: "base64", wantErr: true},
+		{path: "bad", decode: "hex", wantErr: true},
+		{path: "name", decode: "rot13", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ExtractValue(data, tt.path, tt.decode)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ExtractValue(%q, %q) error = %v, wantErr %v", tt.path, tt.decode, err, tt.wantErr)
+			continue
+		}
+		if err == nil && string(got) != tt.want {
+			t.Errorf("ExtractValue(%q, %q) = %s, want %s", tt.path, tt.decode, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package operations
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	jsonfile "github.com/vampire/je/internal/json"
+)
+
+var (
+	pointerEscapes   = strings.NewReplacer("~0", "", "~1", "")
+	pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+)
+
+// splitFragment separates a file reference such as other.json#/db/host into
+// the file name and the fragment after the first #. A file whose name
+// contains # can still be referenced whole.
+func splitFragment(value string) (file, fragment string, ok bool) {
+	if _, err := os.Stat(value); err == nil {
+		return value, "", false
+	}
+	return strings.Cut(value, "#")
+}
+
+// extractFragment returns the raw JSON at fragment within doc. A fragment
+// starting with / is a JSON Pointer (RFC 6901); anything else is a gjson
+// path. An empty fragment selects the whole document.
+func extractFragment(doc, fragment string) (string, error) {
+	if fragment == "" {
+		return doc, nil
+	}
+	path := fragment
+	if strings.HasPrefix(fragment, "/") {
+		var err error
+		if path, err = pointerToPath(fragment); err != nil {
+			return "", err
+		}
+	}
+
+	result := gjson.Get(doc, path)
+	if !result.Exists() {
+		return "", newPathNotFoundError(doc, path)
+	}
+	return result.Raw, nil
+}
+
+// pointerToPath converts a JSON Pointer into the equivalent gjson path.
+func pointerToPath(pointer string) (string, error) {
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		if strings.Contains(pointerEscapes.Replace(token), "~") {
+			return "", fmt.Errorf("invalid JSON Pointer %q: bad escape in %q", pointer, token)
+		}
+		token = pointerUnescaper.Replace(token)
+		if _, err := strconv.Atoi(token); err != nil {
+			token = jsonfile.EscapePathKey(token)
+		}
+		tokens[i] = token
+	}
+	return strings.Join(tokens, "."), nil
+}
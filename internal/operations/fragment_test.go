@@ -0,0 +1,62 @@
+package operations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestFragmentAssignments(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared.json")
+	content := `{"db":{"host":"db.internal","ports":[5432,5433]},"a/b":{"c~d":true},"meta":{"a|b":1,"@x":2,"#":3,"!y":4,"k=v":5,"50%":6,"<a>":7,"[0]":8,"{k}":9}}`
+	if err := os.WriteFile(shared, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		arg      string
+		expected string
+		wantErr  bool
+	}{
+		{name: "json pointer", input: `{}`, arg: "host:@" + shared + "#/db/host", expected: `{"host":"db.internal"}`},
+		{name: "pointer into array", input: `{}`, arg: "port:@" + shared + "#/db/ports/1", expected: `{"port":5433}`},
+		{name: "pointer escapes", input: `{}`, arg: "flag:@" + shared + "#/a~1b/c~0d", expected: `{"flag":true}`},
+		{name: "pointer with pipe", input: `{}`, arg: "v:@" + shared + "#/meta/a|b", expected: `{"v":1}`},
+		{name: "pointer with at sign", input: `{}`, arg: "v:@" + shared + "#/meta/@x", expected: `{"v":2}`},
+		{name: "pointer with hash", input: `{}`, arg: "v:@" + shared + "#/meta/#", expected: `{"v":3}`},
+		{name: "pointer with bang", input: `{}`, arg: "v:@" + shared + "#/meta/!y", expected: `{"v":4}`},
+		{name: "pointer with equals", input: `{}`, arg: "v:@" + shared + "#/meta/k=v", expected: `{"v":5}`},
+		{name: "pointer with percent", input: `{}`, arg: "v:@" + shared + "#/meta/50%", expected: `{"v":6}`},
+		{name: "pointer with angle brackets", input: `{}`, arg: "v:@" + shared + "#/meta/<a>", expected: `{"v":7}`},
+		{name: "pointer with square brackets", input: `{}`, arg: "v:@" + shared + "#/meta/[0]", expected: `{"v":8}`},
+		{name: "pointer with braces", input: `{}`, arg: "v:@" + shared + "#/meta/{k}", expected: `{"v":9}`},
+		{name: "gjson path", input: `{}`, arg: "db:@" + shared + "#db.ports", expected: `{"db":[5432,5433]}`},
+		{name: "whole file", input: `{}`, arg: "all:@" + shared, expected: `{"all":` + content + `}`},
+		{name: "missing fragment", input: `{}`, arg: "x:@" + shared + "#/db/user", wantErr: true},
+		{name: "bad pointer escape", input: `{}`, arg: "x:@" + shared + "#/db/~2", wantErr: true},
+		{name: "self copy", input: `{"db":{"host":"h"}}`, arg: "backup.db:=@.#db", expected: `{"db":{"host":"h"},"backup":{"db":{"host":"h"}}}`},
+		{name: "self pointer", input: `{"db":{"host":"h"}}`, arg: "host:=@.#/db/host", expected: `{"db":{"host":"h"},"host":"h"}`},
+		{name: "self missing", input: `{"db":{}}`, arg: "x:=@.#dbx", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignments, err := parser.ParseAssignments([]string{tt.arg})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := ApplyAssignments([]byte(tt.input), assignments)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyAssignments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && string(got) != tt.expected {
+				t.Errorf("got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package operations
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// IndexOutOfRangeError reports an array index beyond the end of an array.
+// Indices must be sequential: writing at the current length appends, and
+// anything further would pad the array with nulls.
+type IndexOutOfRangeError struct {
+	Path   string // Path of the array
+	Index  int    // Index as written, before negative indices are resolved
+	Length int
+}
+
+func (e *IndexOutOfRangeError) Error() string {
+	msg := fmt.Sprintf("index %d is out of range for array %q of length %d", e.Index, e.Path, e.Length)
+	if e.Index > e.Length {
+		msg += " (use [] to append)"
+	}
+	return msg
+}
+
+// resolvePath checks every array index in path against the document and
+// returns the path with negative indices resolved from the end of their
+// arrays. Unless PadArrays is set, indices past the end of an array, or
+// above 0 for an array that does not exist yet, are rejected.
+func resolvePath(jsonStr, path string, opts Options) (string, error) {
+	segments := splitPath(path)
+	current := gjson.Parse(jsonStr)
+	exists := true
+
+	for i, seg := range segments {
+		parent := strings.Join(segments[:i], ".")
+		index, isIndex := parseIndex(seg)
+
+		switch {
+		case exists && current.IsArray():
+			if !isIndex {
+				break
+			}
+			length := len(current.Array())
+			resolved := index
+			if resolved < 0 {
+				resolved += length
+			}
+			if resolved < 0 || (resolved > length && !opts.PadArrays) {
+				return "", &IndexOutOfRangeError{Path: parent, Index: index, Length: length}
+			}
+			segments[i] = strconv.Itoa(resolved)
+		case !exists || !current.Exists():
+			// sjson creates an array for a numeric key under a missing parent
+			if isIndex && index != 0 && !opts.PadArrays {
+				return "", &IndexOutOfRangeError{Path: parent, Index: index, Length: 0}
+			}
+			exists = false
+			continue
+		}
+
+		current = current.Get(segments[i])
+		exists = current.Exists()
+	}
+
+	return strings.Join(segments, "."), nil
+}
+
+// parseIndex reports whether a path segment is an array index.
+func parseIndex(seg string) (int, bool) {
+	if seg == "" || seg == "-" {
+		return 0, false
+	}
+	digits := strings.TrimPrefix(seg, "-")
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	n, err := strconv.Atoi(seg)
+	return n, err == nil
+}
+
+// splitPath splits a gjson path on unescaped dots, keeping escapes intact.
+func splitPath(path string) []string {
+	var segments []string
+	var current strings.Builder
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			current.WriteByte(path[i])
+			if i+1 < len(path) {
+				i++
+				current.WriteByte(path[i])
+			}
+		case '.':
+			segments = append(segments, current.String())
+			current.Reset()
+		default:
+			current.WriteByte(path[i])
+		}
+	}
+	return append(segments, current.String())
+}
@@ -0,0 +1,101 @@
+package operations
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestArrayIndices(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		path     string
+		opts     Options
+		expected string
+		wantErr  bool
+	}{
+		{name: "replace existing", input: `{"a":[1,2]}`, path: "a.1", expected: `{"a":[1,9]}`},
+		{name: "next index appends", input: `{"a":[1,2]}`, path: "a.2", expected: `{"a":[1,2,9]}`},
+		{name: "gap rejected", input: `{"a":[1,2]}`, path: "a.5", wantErr: true},
+		{name: "gap padded", input: `{"a":[1,2]}`, path: "a.4", opts: Options{PadArrays: true}, expected: `{"a":[1,2,null,null,9]}`},
+		{name: "negative from end", input: `{"a":[1,2,3]}`, path: "a.-1", expected: `{"a":[1,2,9]}`},
+		{name: "negative nested", input: `{"a":[{"b":1},{"b":2}]}`, path: "a.-2.b", expected: `{"a":[{"b":9},{"b":2}]}`},
+		{name: "negative before start", input: `{"a":[1]}`, path: "a.-2", wantErr: true},
+		{name: "new array from zero", input: `{}`, path: "a.0", expected: `{"a":[9]}`},
+		{name: "new array with gap", input: `{}`, path: "a.1", wantErr: true},
+		{name: "object numeric key", input: `{"a":{"5":1}}`, path: "a.5", expected: `{"a":{"5":9}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignments := []parser.Assignment{{Path: tt.path, Operator: parser.OpAssignJSON, Value: "9"}}
+			got, err := ApplyAssignmentsWithOptions([]byte(tt.input), assignments, tt.opts)
+			if tt.wantErr {
+				var indexErr *IndexOutOfRangeError
+				if !errors.As(err, &indexErr) {
+					t.Fatalf("error = %v, want *IndexOutOfRangeError", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(got) != tt.expected {
+				t.Errorf("got %s, want %s", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIndexOutOfRangeError(t *testing.T) {
+	input := []byte(`{"items":[1,2]}`)
+	tests := map[string]string{
+		"items.5":  `index 5 is out of range for array "items" of length 2 (use [] to append)`,
+		"items.-5": `index -5 is out of range for array "items" of length 2`,
+	}
+	for path, want := range tests {
+		_, err := ApplyAssignments(input, []parser.Assignment{{Path: path, Operator: parser.OpAssignJSON, Value: "9"}})
+		var indexErr *IndexOutOfRangeError
+		if !errors.As(err, &indexErr) || indexErr.Error() != want {
+			t.Errorf("%s: error = %v, want %s", path, err, want)
+		}
+	}
+}
+
+func TestDeleteMissingIndex(t *testing.T) {
+	input := `{"items":[1,2]}`
+	for _, path := range []string{"items.5", "items.-5", "items.5.name", "missing.3"} {
+		got, err := ApplyAssignments([]byte(input), []parser.Assignment{{Path: path, Operator: parser.OpAssignJSON}})
+		if err != nil {
+			t.Errorf("deleting %s: error = %v", path, err)
+			continue
+		}
+		if string(got) != input {
+			t.Errorf("deleting %s: got %s, want %s", path, got, input)
+		}
+	}
+}
+
+func TestArrayIndicesInHelpers(t *testing.T) {
+	input := `{"groups":[{"tags":["a"]},{"tags":["b"]}]}`
+	assignments := []parser.Assignment{
+		{Path: "groups.-1.tags[]", Operator: parser.OpAppendArray, Value: "c"},
+		{Path: "groups.[].tags.-1", Operator: parser.OpArrayMap, Value: "z"},
+	}
+
+	got, err := ApplyAssignments([]byte(input), assignments)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := `{"groups":[{"tags":["z"]},{"tags":["b","z"]}]}`
+	if string(got) != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+
+	assignments = []parser.Assignment{{Path: "groups.[].tags.3", Operator: parser.OpArrayMap, Value: "z"}}
+	if _, err := ApplyAssignments([]byte(input), assignments); err == nil {
+		t.Error("expected out of range error for array map")
+	}
+}
@@ -1,35 +1,40 @@
 package operations
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"strconv"
 	"strings"
 )
 
-// parseJSONValue attempts to parse a string as a JSON value.
-// It handles special cases like null, true, false, and numbers.
-func parseJSONValue(value string) (interface{}, error) {
-	// Try parsing as valid JSON first
-	var v interface{}
-	if err := json.Unmarshal([]byte(value), &v); err == nil {
-		return v, nil
+// parseJSONValue validates a string as a JSON value and returns it as raw JSON.
+// Numbers are kept exactly as written so large integers and decimals are not
+// rounded. It also accepts loose forms like True, NULL and +5.
+func parseJSONValue(value string) (string, error) {
+	// Valid JSON is used verbatim, compacted to fit inline
+	if json.Valid([]byte(value)) {
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, []byte(value)); err != nil {
+			return "", err
+		}
+		return buf.String(), nil
 	}
 
 	// Try parsing as simple values
 	switch strings.ToLower(value) {
 	case "null":
-		return nil, nil
+		return "null", nil
 	case "true":
-		return true, nil
+		return "true", nil
 	case "false":
-		return false, nil
+		return "false", nil
 	default:
-		// Try as number
-		if f, err := strconv.ParseFloat(value, 64); err == nil {
-			return f, nil
+		// Accept a leading + on an otherwise valid number, keeping the
+		// digits as written
+		if rest := strings.TrimPrefix(value, "+"); rest != value && rest != "" &&
+			rest[0] >= '0' && rest[0] <= '9' && json.Valid([]byte(rest)) {
+			return rest, nil
 		}
-		return nil, fmt.Errorf("invalid JSON value: %q", value)
+		return "", fmt.Errorf("invalid JSON value: %q", value)
 	}
 }
-
@@ -1,22 +1,50 @@
 package operations
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
+	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
+	jsonfile "github.com/vampire/je/internal/json"
 	"github.com/vampire/je/internal/parser"
 )
 
+// Options controls how assignments are applied.
+type Options struct {
+	// StrictPaths refuses to create keys that do not already exist,
+	// so typos in paths fail instead of adding new branches.
+	StrictPaths bool
+
+	// PreserveTypes refuses to change the JSON type of an existing value,
+	// such as a number becoming a string or an object becoming a scalar.
+	PreserveTypes bool
+
+	// CoerceTypes converts new values to the existing type where the
+	// conversion is lossless (for example "8080" to 8080) instead of failing.
+	// It only applies together with PreserveTypes.
+	CoerceTypes bool
+
+	// PadArrays allows writing past the end of an array, filling the gap
+	// with nulls. Without it, indices must be sequential: at most the
+	// current length, or negative to count from the end.
+	PadArrays bool
+}
+
 // ApplyAssignments applies a list of assignments to JSON data
 func ApplyAssignments(data []byte, assignments []parser.Assignment) ([]byte, error) {
+	return ApplyAssignmentsWithOptions(data, assignments, Options{})
+}
+
+// ApplyAssignmentsWithOptions applies a list of assignments to JSON data using opts
+func ApplyAssignmentsWithOptions(data []byte, assignments []parser.Assignment, opts Options) ([]byte, error) {
 	jsonStr := string(data)
 
 	for _, assignment := range assignments {
 		var err error
-		jsonStr, err = applyAssignment(jsonStr, assignment)
+		jsonStr, err = applyAssignment(jsonStr, assignment, opts)
 		if err != nil {
 			return nil, fmt.Errorf("failed to apply %s: %w", assignment.Path, err)
 		}
@@ -25,32 +53,76 @@ func ApplyAssignments(data []byte, assignments []parser.Assignment) ([]byte, err
 	return []byte(jsonStr), nil
 }
 
-func applyAssignment(jsonStr string, assignment parser.Assignment) (string, error) {
+func applyAssignment(jsonStr string, assignment parser.Assignment, opts Options) (string, error) {
+	if assignment.Operator != parser.OpArrayMap && assignment.Operator != parser.OpArrayMapJSON {
+		// Array maps resolve their base and property paths separately
+		base, suffix := assignment.Path, ""
+		if strings.HasSuffix(base, "[]") {
+			base, suffix = strings.TrimSuffix(base, "[]"), "[]"
+		}
+		resolved, err := resolvePath(jsonStr, base, opts)
+		var indexErr *IndexOutOfRangeError
+		if errors.As(err, &indexErr) && assignment.Operator == parser.OpAssignJSON && assignment.Value == "" {
+			// Deleting an element that does not exist leaves nothing to do
+			return jsonStr, nil
+		}
+		if err != nil {
+			return "", err
+		}
+		assignment.Path = resolved + suffix
+	}
+
+	if err := checkStrictPath(jsonStr, assignment, opts); err != nil {
+		return "", err
+	}
+
 	switch assignment.Operator {
 	case parser.OpAssignString:
-		return applyStringAssignment(jsonStr, assignment.Path, assignment.Value)
+		return applyStringAssignment(jsonStr, assignment.Path, assignment.Value, opts)
 
 	case parser.OpAssignJSON:
-		return applyJSONAssignment(jsonStr, assignment.Path, assignment.Value)
+		return applyJSONAssignment(jsonStr, assignment.Path, assignment.Value, opts)
 
 	case parser.OpAssignFile:
-		content, err := os.ReadFile(assignment.Value)
+		content, err := readFileValue(assignment.Value)
 		if err != nil {
-			return "", fmt.Errorf("failed to read file %s: %w", assignment.Value, err)
+			return "", err
 		}
-		return applyStringAssignment(jsonStr, assignment.Path, string(content))
+		return applyStringAssignment(jsonStr, assignment.Path, content, opts)
 
 	case parser.OpAssignJSONFile:
-		content, err := os.ReadFile(assignment.Value)
+		file, fragment, _ := splitFragment(assignment.Value)
+		content, err := os.ReadFile(file)
 		if err != nil {
-			return "", fmt.Errorf("failed to read file %s: %w", assignment.Value, err)
+			return "", fmt.Errorf("failed to read file %s: %w", file, err)
 		}
 		// Validate JSON
-		var v interface{}
-		if err := json.Unmarshal(content, &v); err != nil {
-			return "", fmt.Errorf("invalid JSON in file %s: %w", assignment.Value, err)
+		if err := jsonfile.ValidateFile(content, file); err != nil {
+			return "", fmt.Errorf("invalid JSON in file %s: %w", file, err)
+		}
+		value, err := extractFragment(string(content), fragment)
+		if err != nil {
+			return "", fmt.Errorf("in file %s: %w", file, err)
 		}
-		return applyJSONAssignment(jsonStr, assignment.Path, string(content))
+		return applyJSONAssignment(jsonStr, assignment.Path, value, opts)
+
+	case parser.OpAssignSelf:
+		fragment, ok := strings.CutPrefix(assignment.Value, "#")
+		if !ok && assignment.Value != "" {
+			return "", fmt.Errorf("invalid reference @.%s: expected @.#path", assignment.Value)
+		}
+		value, err := extractFragment(jsonStr, fragment)
+		if err != nil {
+			return "", err
+		}
+		return applyJSONAssignment(jsonStr, assignment.Path, value, opts)
+
+	case parser.OpAssignEnv:
+		value, ok := os.LookupEnv(assignment.Value)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", assignment.Value)
+		}
+		return applyStringAssignment(jsonStr, assignment.Path, value, opts)
 
 	case parser.OpAppendArray:
 		return applyArrayAppend(jsonStr, assignment.Path, assignment.Value, false)
@@ -59,25 +131,30 @@ func applyAssignment(jsonStr string, assignment parser.Assignment) (string, erro
 		return applyArrayAppend(jsonStr, assignment.Path, assignment.Value, true)
 
 	case parser.OpArrayMap:
-		return applyArrayMap(jsonStr, assignment.Path, assignment.Value, false)
+		return applyArrayMap(jsonStr, assignment.Path, assignment.Value, false, opts)
 
 	case parser.OpArrayMapJSON:
-		return applyArrayMap(jsonStr, assignment.Path, assignment.Value, true)
+		return applyArrayMap(jsonStr, assignment.Path, assignment.Value, true, opts)
 
 	default:
 		return "", fmt.Errorf("unknown operator type: %d", assignment.Operator)
 	}
 }
 
-func applyStringAssignment(jsonStr, path, value string) (string, error) {
-	result, err := sjson.Set(jsonStr, path, value)
+func applyStringAssignment(jsonStr, path, value string, opts Options) (string, error) {
+	raw, err := checkType(jsonStr, path, jsonfile.QuoteString(value), opts)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := sjson.SetRaw(jsonStr, path, raw)
 	if err != nil {
 		return "", err
 	}
 	return result, nil
 }
 
-func applyJSONAssignment(jsonStr, path, value string) (string, error) {
+func applyJSONAssignment(jsonStr, path, value string, opts Options) (string, error) {
 	// Handle special case: empty value means delete
 	if value == "" {
 		result, err := sjson.Delete(jsonStr, path)
@@ -88,12 +165,15 @@ func applyJSONAssignment(jsonStr, path, value string) (string, error) {
 	}
 
 	// Parse the value as JSON
-	v, err := parseJSONValue(value)
+	raw, err := parseJSONValue(value)
 	if err != nil {
 		return "", fmt.Errorf("invalid JSON value for %q: %w", path, err)
 	}
+	if raw, err = checkType(jsonStr, path, raw, opts); err != nil {
+		return "", err
+	}
 
-	result, err := sjson.Set(jsonStr, path, v)
+	result, err := sjson.SetRaw(jsonStr, path, raw)
 	if err != nil {
 		return "", err
 	}
@@ -112,26 +192,27 @@ func applyArrayAppend(jsonStr, path, value string, isJSON bool) (string, error)
 	}
 
 	// Prepare value to append
-	var appendValue interface{}
+	appendValue := jsonfile.QuoteString(value)
 	if isJSON {
 		appendValue, err = parseJSONValue(value)
 		if err != nil {
 			return "", fmt.Errorf("invalid JSON value for array append: %w", err)
 		}
-	} else {
-		appendValue = value
 	}
 
 	// Append to array
 	return appendToArray(jsonStr, basePath, appendValue)
 }
 
-func applyArrayMap(jsonStr, path, value string, isJSON bool) (string, error) {
+func applyArrayMap(jsonStr, path, value string, isJSON bool, opts Options) (string, error) {
 	// Parse the array map path
 	basePath, property, err := parseArrayMapPath(path)
 	if err != nil {
 		return "", err
 	}
+	if basePath, err = resolvePath(jsonStr, basePath, opts); err != nil {
+		return "", err
+	}
 
 	// Validate the array exists
 	if err := validateArrayPath(jsonStr, basePath); err != nil {
@@ -139,16 +220,37 @@ func applyArrayMap(jsonStr, path, value string, isJSON bool) (string, error) {
 	}
 
 	// Prepare the value
-	var setValue interface{}
+	setValue := jsonfile.QuoteString(value)
 	if isJSON {
 		setValue, err = parseJSONValue(value)
 		if err != nil {
 			return "", fmt.Errorf("invalid JSON value for array map: %w", err)
 		}
-	} else {
-		setValue = value
 	}
 
 	// Apply to each array element
-	return applyToArrayElements(jsonStr, basePath, property, setValue)
+	return applyToArrayElements(jsonStr, basePath, property, setValue, opts)
+}
+
+// checkStrictPath rejects assignments that would create a new key when
+// StrictPaths is set. Appends and array maps must target an existing array.
+func checkStrictPath(jsonStr string, assignment parser.Assignment, opts Options) error {
+	if !opts.StrictPaths {
+		return nil
+	}
+
+	path := assignment.Path
+	switch assignment.Operator {
+	case parser.OpAppendArray, parser.OpAppendArrayJSON:
+		path = strings.TrimSuffix(path, "[]")
+	case parser.OpArrayMap, parser.OpArrayMapJSON:
+		// validateArrayPath reports missing arrays and applyToArrayElements
+		// missing properties
+		return nil
+	}
+
+	if !gjson.Get(jsonStr, path).Exists() {
+		return newPathNotFoundError(jsonStr, path)
+	}
+	return nil
 }
@@ -167,9 +167,103 @@ func TestFileOperations(t *testing.T) {
 	}
 }
 
+func TestNumberPrecision(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		assignment parser.Assignment
+		expected   string
+	}{
+		{
+			name:       "large integer",
+			input:      `{}`,
+			assignment: parser.Assignment{Path: "id", Operator: parser.OpAssignJSON, Value: "12345678901234567890"},
+			expected:   `{"id":12345678901234567890}`,
+		},
+		{
+			name:       "decimal keeps trailing zero",
+			input:      `{}`,
+			assignment: parser.Assignment{Path: "price", Operator: parser.OpAssignJSON, Value: "1.0"},
+			expected:   `{"price":1.0}`,
+		},
+		{
+			name:       "exponent form",
+			input:      `{}`,
+			assignment: parser.Assignment{Path: "n", Operator: parser.OpAssignJSON, Value: "1.5e300"},
+			expected:   `{"n":1.5e300}`,
+		},
+		{
+			name:       "untouched numbers preserved",
+			input:      `{"big":98765432109876543210,"x":1}`,
+			assignment: parser.Assignment{Path: "x", Operator: parser.OpAssignJSON, Value: "2"},
+			expected:   `{"big":98765432109876543210,"x":2}`,
+		},
+		{
+			name:       "leading plus",
+			input:      `{}`,
+			assignment: parser.Assignment{Path: "n", Operator: parser.OpAssignJSON, Value: "+12345678901234567890"},
+			expected:   `{"n":12345678901234567890}`,
+		},
+		{
+			name:       "leading plus decimal",
+			input:      `{}`,
+			assignment: parser.Assignment{Path: "n", Operator: parser.OpAssignJSON, Value: "+1.50"},
+			expected:   `{"n":1.50}`,
+		},
+		{
+			name:       "array append",
+			input:      `{"ids":[]}`,
+			assignment: parser.Assignment{Path: "ids[]", Operator: parser.OpAppendArrayJSON, Value: "18446744073709551615"},
+			expected:   `{"ids":[18446744073709551615]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ApplyAssignments([]byte(tt.input), []parser.Assignment{tt.assignment})
+			if err != nil {
+				t.Fatalf("ApplyAssignments() error = %v", err)
+			}
+			if string(result) != tt.expected {
+				t.Errorf("ApplyAssignments() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInvalidLooseNumbers(t *testing.T) {
+	for _, value := range []string{"+", "++1", "+-1", "+[1]", "+01", "1e"} {
+		assignment := parser.Assignment{Path: "n", Operator: parser.OpAssignJSON, Value: value}
+		if result, err := ApplyAssignments([]byte(`{}`), []parser.Assignment{assignment}); err == nil {
+			t.Errorf("ApplyAssignments(%q) = %s, want error", value, result)
+		}
+	}
+}
+
 // Helper function to compare JSON objects
 func jsonEqual(a, b interface{}) bool {
 	aJSON, _ := json.Marshal(a)
 	bJSON, _ := json.Marshal(b)
 	return string(aJSON) == string(bJSON)
 }
+
+func TestEnvAssignment(t *testing.T) {
+	t.Setenv("JE_TEST_TOKEN", "s3cret")
+
+	got, err := ApplyAssignments([]byte(`{}`), []parser.Assignment{
+		{Path: "auth.token", Operator: parser.OpAssignEnv, Value: "JE_TEST_TOKEN"},
+	})
+	if err != nil {
+		t.Fatalf("ApplyAssignments() error = %v", err)
+	}
+	if string(got) != `{"auth":{"token":"s3cret"}}` {
+		t.Errorf("got %s", got)
+	}
+
+	_, err = ApplyAssignments([]byte(`{}`), []parser.Assignment{
+		{Path: "auth.token", Operator: parser.OpAssignEnv, Value: "JE_TEST_UNSET"},
+	})
+	if err == nil {
+		t.Error("expected an error for an unset variable")
+	}
+}
@@ -0,0 +1,109 @@
+package operations
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	jsonfile "github.com/vampire/je/internal/json"
+)
+
+// maxSuggestions is the number of similar paths offered for a missing path.
+const maxSuggestions = 3
+
+// PathNotFoundError reports a path that does not exist in the document,
+// along with existing paths that look like a likely typo fix.
+type PathNotFoundError struct {
+	Path        string
+	Suggestions []string
+}
+
+func (e *PathNotFoundError) Error() string {
+	msg := fmt.Sprintf("path %q does not exist", e.Path)
+	if len(e.Suggestions) == 0 {
+		return msg
+	}
+	quoted := make([]string, len(e.Suggestions))
+	for i, s := range e.Suggestions {
+		quoted[i] = fmt.Sprintf("%q", s)
+	}
+	return fmt.Sprintf("%s (did you mean %s?)", msg, strings.Join(quoted, " or "))
+}
+
+// newPathNotFoundError builds a PathNotFoundError with suggestions drawn from jsonStr.
+func newPathNotFoundError(jsonStr, path string) *PathNotFoundError {
+	return &PathNotFoundError{Path: path, Suggestions: suggestPaths(jsonStr, path)}
+}
+
+// suggestPaths returns the existing paths closest to path by edit distance,
+// ignoring any that differ by more than a third of the path's length.
+func suggestPaths(jsonStr, path string) []string {
+	type candidate struct {
+		path     string
+		distance int
+	}
+
+	limit := max(2, len(path)/3)
+	var candidates []candidate
+	for _, p := range collectPaths(gjson.Parse(jsonStr), "") {
+		if d := levenshtein(path, p); d <= limit {
+			candidates = append(candidates, candidate{p, d})
+		}
+	}
+
+	// Only the closest matches are worth offering
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].distance < candidates[j].distance })
+	var suggestions []string
+	for i := 0; i < len(candidates) && i < maxSuggestions; i++ {
+		if candidates[i].distance > candidates[0].distance {
+			break
+		}
+		suggestions = append(suggestions, candidates[i].path)
+	}
+	return suggestions
+}
+
+// collectPaths lists every object key and array index path in document order.
+func collectPaths(value gjson.Result, prefix string) []string {
+	var paths []string
+
+	switch {
+	case value.IsObject():
+		value.ForEach(func(key, field gjson.Result) bool {
+			p := jsonfile.JoinPath(prefix, key.String())
+			paths = append(paths, p)
+			paths = append(paths, collectPaths(field, p)...)
+			return true
+		})
+	case value.IsArray():
+		for i, item := range value.Array() {
+			p := jsonfile.JoinPath(prefix, fmt.Sprint(i))
+			paths = append(paths, p)
+			paths = append(paths, collectPaths(item, p)...)
+		}
+	}
+	return paths
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,117 @@
+package operations
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestStrictPaths(t *testing.T) {
+	input := `{"database": {"host": "localhost", "port": 5432}, "tags": ["a"], "servers": [{"host": "a"}, {"host": "b"}], "log|level": "info"}`
+
+	tests := []struct {
+		name        string
+		assignment  parser.Assignment
+		wantErr     bool
+		suggestions []string
+	}{
+		{
+			name:       "existing path",
+			assignment: parser.Assignment{Path: "database.host", Operator: parser.OpAssignString, Value: "db"},
+		},
+		{
+			name:        "typo in parent",
+			assignment:  parser.Assignment{Path: "databse.host", Operator: parser.OpAssignString, Value: "db"},
+			wantErr:     true,
+			suggestions: []string{"database.host"},
+		},
+		{
+			name:        "typo in leaf",
+			assignment:  parser.Assignment{Path: "database.prot", Operator: parser.OpAssignJSON, Value: "1"},
+			wantErr:     true,
+			suggestions: []string{"database.port"},
+		},
+		{
+			name:       "append to existing array",
+			assignment: parser.Assignment{Path: "tags[]", Operator: parser.OpAppendArray, Value: "b"},
+		},
+		{
+			name:        "append to missing array",
+			assignment:  parser.Assignment{Path: "tag[]", Operator: parser.OpAppendArray, Value: "b"},
+			wantErr:     true,
+			suggestions: []string{"tags"},
+		},
+		{
+			name:       "array map over existing property",
+			assignment: parser.Assignment{Path: "servers.[].host", Operator: parser.OpArrayMap, Value: "c"},
+		},
+		{
+			name:        "array map over missing property",
+			assignment:  parser.Assignment{Path: "servers.[].hots", Operator: parser.OpArrayMap, Value: "c"},
+			wantErr:     true,
+			suggestions: []string{"servers.0.host"},
+		},
+		{
+			name:        "suggestion escapes path metacharacters",
+			assignment:  parser.Assignment{Path: `log\|levle`, Operator: parser.OpAssignString, Value: "debug"},
+			wantErr:     true,
+			suggestions: []string{`log\|level`},
+		},
+		{
+			name:       "no similar path",
+			assignment: parser.Assignment{Path: "completely.different", Operator: parser.OpAssignString, Value: "x"},
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ApplyAssignmentsWithOptions([]byte(input), []parser.Assignment{tt.assignment}, Options{StrictPaths: true})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyAssignmentsWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				return
+			}
+
+			var notFound *PathNotFoundError
+			if !errors.As(err, &notFound) {
+				t.Fatalf("error = %v, want *PathNotFoundError", err)
+			}
+			if !reflect.DeepEqual(notFound.Suggestions, tt.suggestions) {
+				t.Errorf("Suggestions = %v, want %v", notFound.Suggestions, tt.suggestions)
+			}
+		})
+	}
+}
+
+func TestArrayMapSuggestsPath(t *testing.T) {
+	assignments := []parser.Assignment{
+		{Path: "usres.[].active", Operator: parser.OpArrayMapJSON, Value: "true"},
+	}
+	_, err := ApplyAssignments([]byte(`{"users": [{}]}`), assignments)
+
+	var notFound *PathNotFoundError
+	if !errors.As(err, &notFound) || len(notFound.Suggestions) == 0 || notFound.Suggestions[0] != "users" {
+		t.Errorf("ApplyAssignments() error = %v, want suggestion \"users\"", err)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "abc", 3},
+		{"databse", "database", 1},
+		{"kitten", "sitting", 3},
+		{"same", "same", 0},
+	}
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package operations
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	jsonfile "github.com/vampire/je/internal/json"
+)
+
+// TypeChangeError reports an assignment that would change the JSON type of
+// an existing value while PreserveTypes is set.
+type TypeChangeError struct {
+	Path string
+	From string
+	To   string
+}
+
+func (e *TypeChangeError) Error() string {
+	msg := fmt.Sprintf("refusing to change type of %q from %s to %s", e.Path, e.From, e.To)
+	if e.To == "string" && (e.From == "number" || e.From == "boolean") {
+		msg += fmt.Sprintf(" (use := to assign a %s)", e.From)
+	}
+	return msg
+}
+
+// checkType compares the type of the value at path with the raw JSON about
+// to replace it. It returns the raw value to write, coerced to the existing
+// type when CoerceTypes allows it. Missing and null values accept any type,
+// unless writing a missing value would turn a scalar ancestor into an object
+// or array.
+func checkType(jsonStr, path, raw string, opts Options) (string, error) {
+	if !opts.PreserveTypes {
+		return raw, nil
+	}
+
+	existing := gjson.Get(jsonStr, path)
+	if !existing.Exists() {
+		return raw, checkAncestorType(jsonStr, path)
+	}
+	if existing.Type == gjson.Null {
+		return raw, nil
+	}
+
+	value := gjson.Parse(raw)
+	from, to := typeName(existing), typeName(value)
+	if from == to {
+		return raw, nil
+	}
+
+	if opts.CoerceTypes {
+		if coerced, ok := coerceValue(value, from); ok {
+			return coerced, nil
+		}
+	}
+	return "", &TypeChangeError{Path: path, From: from, To: to}
+}
+
+// checkAncestorType finds the deepest existing ancestor of a missing path
+// and reports a *TypeChangeError if it is a scalar that writing the path
+// would replace with an object or array.
+func checkAncestorType(jsonStr, path string) error {
+	segments := splitPath(path)
+	for i := len(segments) - 1; i > 0; i-- {
+		ancestor := strings.Join(segments[:i], ".")
+		value := gjson.Get(jsonStr, ancestor)
+		if !value.Exists() {
+			continue
+		}
+		if value.IsObject() || value.IsArray() || value.Type == gjson.Null {
+			return nil
+		}
+		to := "object"
+		if _, isIndex := parseIndex(segments[i]); isIndex {
+			to = "array"
+		}
+		return &TypeChangeError{Path: ancestor, From: typeName(value), To: to}
+	}
+	return nil
+}
+
+// coerceValue converts a scalar to the named type when that loses nothing.
+func coerceValue(value gjson.Result, target string) (string, bool) {
+	switch target {
+	case "string":
+		if value.Type == gjson.Number || value.Type == gjson.True || value.Type == gjson.False {
+			return jsonfile.QuoteString(value.Raw), true
+		}
+	case "number":
+		if value.Type == gjson.String && json.Valid([]byte(value.Str)) && gjson.Parse(value.Str).Type == gjson.Number {
+			return value.Str, true
+		}
+	case "boolean":
+		if value.Type == gjson.String && (value.Str == "true" || value.Str == "false") {
+			return value.Str, true
+		}
+	}
+	return "", false
+}
+
+// typeName returns the JSON type of a value as used in error messages.
+func typeName(value gjson.Result) string {
+	switch value.Type {
+	case gjson.String:
+		return "string"
+	case gjson.Number:
+		return "number"
+	case gjson.True, gjson.False:
+		return "boolean"
+	case gjson.Null:
+		return "null"
+	}
+	if value.IsArray() {
+		return "array"
+	}
+	return "object"
+}
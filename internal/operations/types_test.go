@@ -0,0 +1,135 @@
+package operations
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestPreserveTypes(t *testing.T) {
+	input := `{"port": 8080, "name": "api", "debug": false, "db": {"host": "x"}, "note": null}`
+
+	tests := []struct {
+		name       string
+		assignment parser.Assignment
+		coerce     bool
+		expected   string
+		wantErr    bool
+	}{
+		{
+			name:       "same type",
+			assignment: parser.Assignment{Path: "port", Operator: parser.OpAssignJSON, Value: "9090"},
+			expected:   `{"port": 9090, "name": "api", "debug": false, "db": {"host": "x"}, "note": null}`,
+		},
+		{
+			name:       "number to string",
+			assignment: parser.Assignment{Path: "port", Operator: parser.OpAssignString, Value: "9090"},
+			wantErr:    true,
+		},
+		{
+			name:       "number to string coerced",
+			assignment: parser.Assignment{Path: "port", Operator: parser.OpAssignString, Value: "9090"},
+			coerce:     true,
+			expected:   `{"port": 9090, "name": "api", "debug": false, "db": {"host": "x"}, "note": null}`,
+		},
+		{
+			name:       "boolean coerced",
+			assignment: parser.Assignment{Path: "debug", Operator: parser.OpAssignString, Value: "true"},
+			coerce:     true,
+			expected:   `{"port": 8080, "name": "api", "debug": true, "db": {"host": "x"}, "note": null}`,
+		},
+		{
+			name:       "string coerced from number",
+			assignment: parser.Assignment{Path: "name", Operator: parser.OpAssignJSON, Value: "42"},
+			coerce:     true,
+			expected:   `{"port": 8080, "name": "42", "debug": false, "db": {"host": "x"}, "note": null}`,
+		},
+		{
+			name:       "object to scalar cannot be coerced",
+			assignment: parser.Assignment{Path: "db", Operator: parser.OpAssignString, Value: "x"},
+			coerce:     true,
+			wantErr:    true,
+		},
+		{
+			name:       "null accepts any type",
+			assignment: parser.Assignment{Path: "note", Operator: parser.OpAssignString, Value: "hi"},
+			expected:   `{"port": 8080, "name": "api", "debug": false, "db": {"host": "x"}, "note": "hi"}`,
+		},
+		{
+			name:       "new keys accept any type",
+			assignment: parser.Assignment{Path: "extra", Operator: parser.OpAssignJSON, Value: "[]"},
+			expected:   `{"port": 8080, "name": "api", "debug": false, "db": {"host": "x"}, "note": null,"extra":[]}`,
+		},
+		{
+			name:       "nested key under a scalar",
+			assignment: parser.Assignment{Path: "port.b.c", Operator: parser.OpAssignJSON, Value: "1"},
+			wantErr:    true,
+		},
+		{
+			name:       "index under a scalar",
+			assignment: parser.Assignment{Path: "name.0", Operator: parser.OpAssignString, Value: "x"},
+			wantErr:    true,
+		},
+		{
+			name:       "nested key under null",
+			assignment: parser.Assignment{Path: "note.b", Operator: parser.OpAssignJSON, Value: "1"},
+			expected:   `{"port": 8080, "name": "api", "debug": false, "db": {"host": "x"}, "note": {"b":1}}`,
+		},
+		{
+			name:       "nested key under an object",
+			assignment: parser.Assignment{Path: "db.pool.size", Operator: parser.OpAssignJSON, Value: "5"},
+			expected:   `{"port": 8080, "name": "api", "debug": false, "db": {"host": "x","pool":{"size":5}}, "note": null}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{PreserveTypes: true, CoerceTypes: tt.coerce}
+			result, err := ApplyAssignmentsWithOptions([]byte(input), []parser.Assignment{tt.assignment}, opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ApplyAssignmentsWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				var typeErr *TypeChangeError
+				if !errors.As(err, &typeErr) {
+					t.Errorf("error = %v, want *TypeChangeError", err)
+				}
+				return
+			}
+			if string(result) != tt.expected {
+				t.Errorf("ApplyAssignmentsWithOptions() = %s, want %s", result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPreserveTypesAncestor(t *testing.T) {
+	tests := []struct {
+		input, path string
+		want        TypeChangeError
+	}{
+		{`{"a":1}`, "a.b.c", TypeChangeError{Path: "a", From: "number", To: "object"}},
+		{`{"a":"str"}`, "a.0", TypeChangeError{Path: "a", From: "string", To: "array"}},
+	}
+	for _, tt := range tests {
+		assignments := []parser.Assignment{{Path: tt.path, Operator: parser.OpAssignString, Value: "x"}}
+		_, err := ApplyAssignmentsWithOptions([]byte(tt.input), assignments, Options{PreserveTypes: true})
+		var typeErr *TypeChangeError
+		if !errors.As(err, &typeErr) || *typeErr != tt.want {
+			t.Errorf("%s on %s: error = %v, want %v", tt.path, tt.input, err, &tt.want)
+		}
+	}
+}
+
+func TestPreserveTypesArrayMap(t *testing.T) {
+	assignments := []parser.Assignment{
+		{Path: "users.[].age", Operator: parser.OpArrayMap, Value: "30"},
+	}
+	_, err := ApplyAssignmentsWithOptions([]byte(`{"users": [{"age": 1}]}`), assignments, Options{PreserveTypes: true})
+
+	var typeErr *TypeChangeError
+	if !errors.As(err, &typeErr) || typeErr.Path != "users.0.age" {
+		t.Errorf("ApplyAssignmentsWithOptions() error = %v, want type change at users.0.age", err)
+	}
+}
@@ -0,0 +1,28 @@
+package operations
+
+import (
+	"github.com/vampire/je/internal/parser"
+)
+
+// ValidateAssignments applies every assignment to a scratch copy of data and
+// returns a *parser.ValidationError listing each one that fails, indexed by
+// its position in assignments. A failing assignment is skipped so later ones
+// are checked against the document as it would otherwise be. Nothing is written.
+func ValidateAssignments(data []byte, assignments []parser.Assignment, opts Options) error {
+	jsonStr := string(data)
+	var invalid parser.ValidationError
+
+	for i, assignment := range assignments {
+		result, err := applyAssignment(jsonStr, assignment, opts)
+		if err != nil {
+			invalid.Errors = append(invalid.Errors, &parser.ArgumentError{Index: i, Arg: assignment.String(), Err: err})
+			continue
+		}
+		jsonStr = result
+	}
+
+	if len(invalid.Errors) > 0 {
+		return &invalid
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package operations
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vampire/je/internal/parser"
+)
+
+func TestValidateAssignments(t *testing.T) {
+	input := `{"port":8080,"tags":[]}`
+	assignments := []parser.Assignment{
+		{Path: "name", Operator: parser.OpAssignString, Value: "ok"},
+		{Path: "port", Operator: parser.OpAssignJSON, Value: "{bad"},
+		{Path: "cert", Operator: parser.OpAssignFile, Value: "/nonexistent/cert.pem"},
+		{Path: "port", Operator: parser.OpAssignString, Value: "http"},
+		{Path: "tags.3", Operator: parser.OpAssignJSON, Value: "1"},
+	}
+
+	err := ValidateAssignments([]byte(input), assignments, Options{PreserveTypes: true})
+
+	var verr *parser.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %v, want *parser.ValidationError", err)
+	}
+	var indices []int
+	for _, e := range verr.Errors {
+		indices = append(indices, e.Index)
+	}
+	if len(indices) != 4 || indices[0] != 1 || indices[1] != 2 || indices[2] != 3 || indices[3] != 4 {
+		t.Fatalf("failing indices = %v, want [1 2 3 4]", indices)
+	}
+
+	var typeErr *TypeChangeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("expected a *TypeChangeError among %v", err)
+	}
+
+	if err := ValidateAssignments([]byte(input), assignments[:1], Options{}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,144 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ConflictKind classifies how two assignments in one invocation interact.
+type ConflictKind int
+
+const (
+	ConflictOverwrite       ConflictKind = iota // A later write replaces an earlier one
+	ConflictBeneathScalar                       // A write goes beneath a path just set to a scalar
+	ConflictDeleteThenWrite                     // A write follows a delete of the same path
+)
+
+// Conflict describes an assignment whose effect depends on an earlier one.
+type Conflict struct {
+	Kind     ConflictKind
+	Index    int // Position of the later assignment
+	Previous int // Position of the earlier assignment it conflicts with
+	Path     string
+}
+
+func (c Conflict) String() string {
+	var what string
+	switch c.Kind {
+	case ConflictOverwrite:
+		what = "overwrites"
+	case ConflictBeneathScalar:
+		what = "writes beneath the scalar set by"
+	case ConflictDeleteThenWrite:
+		what = "writes to the path deleted by"
+	}
+	return fmt.Sprintf("assignment %d (%s) %s assignment %d", c.Index+1, c.Path, what, c.Previous+1)
+}
+
+// ConflictError reports conflicting assignments when they are not allowed.
+type ConflictError struct {
+	Conflicts []Conflict
+}
+
+func (e *ConflictError) Error() string {
+	lines := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		lines[i] = c.String()
+	}
+	return "conflicting assignments: " + strings.Join(lines, "; ")
+}
+
+// writeKind describes what an assignment does to its target path.
+type writeKind int
+
+const (
+	writeScalar    writeKind = iota // Replaces the target with a scalar
+	writeContainer                  // Replaces the target with an object, array or file contents
+	writeDelete                     // Removes the target
+	writeInto                       // Modifies the array at the target in place
+)
+
+type plannedWrite struct {
+	segments []string
+	kind     writeKind
+}
+
+// DetectConflicts finds assignments whose result depends on the order they
+// are given in: writing the same path twice, writing beneath a path that an
+// earlier assignment set to a scalar, and writing to a path after deleting
+// it. Each assignment is reported at most once, against the closest earlier
+// assignment it conflicts with.
+func DetectConflicts(assignments []Assignment) []Conflict {
+	var conflicts []Conflict
+	planned := make([]plannedWrite, len(assignments))
+
+	for j, a := range assignments {
+		planned[j] = planWrite(a)
+		for i := j - 1; i >= 0; i-- {
+			if kind, ok := conflictBetween(planned[i], planned[j]); ok {
+				conflicts = append(conflicts, Conflict{Kind: kind, Index: j, Previous: i, Path: a.Path})
+				break
+			}
+		}
+	}
+	return conflicts
+}
+
+func planWrite(a Assignment) plannedWrite {
+	switch a.Operator {
+	case OpAppendArray, OpAppendArrayJSON:
+		return plannedWrite{segments: ParsePath(strings.TrimSuffix(a.Path, "[]")), kind: writeInto}
+	case OpArrayMap, OpArrayMapJSON:
+		base := a.Path[:strings.Index(a.Path, "[].")]
+		return plannedWrite{segments: ParsePath(strings.TrimSuffix(base, ".")), kind: writeInto}
+	case OpAssignJSON:
+		value := strings.TrimSpace(a.Value)
+		switch {
+		case value == "":
+			return plannedWrite{segments: ParsePath(a.Path), kind: writeDelete}
+		case strings.HasPrefix(value, "{") || strings.HasPrefix(value, "["):
+			return plannedWrite{segments: ParsePath(a.Path), kind: writeContainer}
+		}
+		return plannedWrite{segments: ParsePath(a.Path), kind: writeScalar}
+	case OpAssignJSONFile, OpAssignSelf:
+		return plannedWrite{segments: ParsePath(a.Path), kind: writeContainer}
+	default:
+		return plannedWrite{segments: ParsePath(a.Path), kind: writeScalar}
+	}
+}
+
+// conflictBetween reports how the later write interacts with the earlier one.
+func conflictBetween(earlier, later plannedWrite) (ConflictKind, bool) {
+	// Writing into an array after replacing it is the usual way to build one
+	intoEarlier := hasPrefix(later.segments, earlier.segments) &&
+		(len(later.segments) > len(earlier.segments) || later.kind == writeInto)
+
+	switch {
+	case earlier.kind == writeDelete && (intoEarlier || equalSegments(earlier.segments, later.segments)):
+		if later.kind == writeDelete {
+			return ConflictOverwrite, true
+		}
+		return ConflictDeleteThenWrite, true
+	case earlier.kind == writeScalar && intoEarlier:
+		return ConflictBeneathScalar, true
+	case later.kind != writeInto && hasPrefix(earlier.segments, later.segments):
+		return ConflictOverwrite, true
+	}
+	return 0, false
+}
+
+func hasPrefix(segments, prefix []string) bool {
+	if len(prefix) > len(segments) {
+		return false
+	}
+	for i := range prefix {
+		if segments[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalSegments(a, b []string) bool {
+	return len(a) == len(b) && hasPrefix(a, b)
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetectConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want []Conflict
+	}{
+		{
+			name: "independent paths",
+			args: []string{"a.b=1", "a.c=2", "tags[]=x"},
+		},
+		{
+			name: "building a container",
+			args: []string{"a:={}", "a.b=1", "x:=[]", "x[]=1", "x.[].y=2"},
+		},
+		{
+			name: "same path twice",
+			args: []string{"a=1", "b=2", "a:=3"},
+			want: []Conflict{{Kind: ConflictOverwrite, Index: 2, Previous: 0, Path: "a"}},
+		},
+		{
+			name: "write beneath scalar",
+			args: []string{"a=1", "a.b=2"},
+			want: []Conflict{{Kind: ConflictBeneathScalar, Index: 1, Previous: 0, Path: "a.b"}},
+		},
+		{
+			name: "append to scalar",
+			args: []string{"x:=5", "x[]=1"},
+			want: []Conflict{{Kind: ConflictBeneathScalar, Index: 1, Previous: 0, Path: "x[]"}},
+		},
+		{
+			name: "delete then write",
+			args: []string{"a:=", "a.b=1"},
+			want: []Conflict{{Kind: ConflictDeleteThenWrite, Index: 1, Previous: 0, Path: "a.b"}},
+		},
+		{
+			name: "delete after building",
+			args: []string{"x:=[]", "x[]=1", "x:="},
+			want: []Conflict{{Kind: ConflictOverwrite, Index: 2, Previous: 1, Path: "x"}},
+		},
+		{
+			name: "parent replaced",
+			args: []string{"a.b=1", "a:={}"},
+			want: []Conflict{{Kind: ConflictOverwrite, Index: 1, Previous: 0, Path: "a"}},
+		},
+		{
+			name: "escaped dots are one key",
+			args: []string{`a\.b=1`, "a.b=2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assignments, err := ParseAssignments(tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := DetectConflicts(assignments); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectConflicts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ExpandEnv replaces ${VAR} and ${VAR:-default} in assignment values with
+// environment variables. The default is used when VAR is unset or empty;
+// without one an unset variable is an error, so typos do not silently
+// become empty strings. $${ produces a literal ${. Values of $= assignments
+// are variable names and are left alone.
+func ExpandEnv(assignments []Assignment) ([]Assignment, error) {
+	expanded := make([]Assignment, len(assignments))
+	for i, a := range assignments {
+		if a.Operator != OpAssignEnv {
+			value, err := expandEnvValue(a.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid assignment %q: %w", a.String(), err)
+			}
+			a.Value = value
+		}
+		expanded[i] = a
+	}
+	return expanded, nil
+}
+
+func expandEnvValue(value string) (string, error) {
+	var b strings.Builder
+	for {
+		start := strings.Index(value, "${")
+		if start < 0 {
+			b.WriteString(value)
+			return b.String(), nil
+		}
+		if start > 0 && value[start-1] == '$' {
+			b.WriteString(value[:start-1] + "${")
+			value = value[start+2:]
+			continue
+		}
+		end := strings.IndexByte(value[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated ${ in %q", value)
+		}
+
+		name, def, hasDefault := strings.Cut(value[start+2:start+end], ":-")
+		resolved, ok := os.LookupEnv(name)
+		switch {
+		case hasDefault && resolved == "":
+			resolved = def
+		case !ok:
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+
+		b.WriteString(value[:start])
+		b.WriteString(resolved)
+		value = value[start+end+1:]
+	}
+}
+
+// EnvAssignments turns environment variables starting with prefix into
+// string assignments: the prefix is dropped, the rest lowercased, and a
+// double underscore separates path segments, so APP_DB__HOST=x with prefix
+// APP_ becomes db.host=x. environ is in the form returned by os.Environ.
+// Assignments are sorted by path.
+func EnvAssignments(prefix string, environ []string) []Assignment {
+	var assignments []Assignment
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok || !strings.HasPrefix(name, prefix) || name == prefix {
+			continue
+		}
+		segments := strings.Split(strings.ToLower(strings.TrimPrefix(name, prefix)), "__")
+		assignments = append(assignments, Assignment{
+			Path:     strings.Join(segments, "."),
+			Operator: OpAssignString,
+			Value:    value,
+		})
+	}
+	sort.Slice(assignments, func(i, j int) bool { return assignments[i].Path < assignments[j].Path })
+	return assignments
+}
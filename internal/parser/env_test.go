@@ -0,0 +1,61 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("JE_HOST", "db.internal")
+	t.Setenv("JE_EMPTY", "")
+
+	tests := []struct {
+		value   string
+		want    string
+		wantErr bool
+	}{
+		{value: "${JE_HOST}", want: "db.internal"},
+		{value: "postgres://${JE_HOST}:5432", want: "postgres://db.internal:5432"},
+		{value: "${JE_MISSING:-localhost}", want: "localhost"},
+		{value: "${JE_EMPTY:-fallback}", want: "fallback"},
+		{value: "${JE_EMPTY}", want: ""},
+		{value: "cost $5 and $${JE_HOST}", want: "cost $5 and ${JE_HOST}"},
+		{value: "${JE_MISSING}", wantErr: true},
+		{value: "${JE_HOST", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ExpandEnv([]Assignment{{Path: "a", Operator: OpAssignString, Value: tt.value}})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ExpandEnv(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got[0].Value != tt.want {
+			t.Errorf("ExpandEnv(%q) = %q, want %q", tt.value, got[0].Value, tt.want)
+		}
+	}
+
+	// $= values are variable names, not templates
+	got, err := ExpandEnv([]Assignment{{Path: "a", Operator: OpAssignEnv, Value: "${X}"}})
+	if err != nil || got[0].Value != "${X}" {
+		t.Errorf("ExpandEnv() on $= = %v, %v", got, err)
+	}
+}
+
+func TestEnvAssignments(t *testing.T) {
+	environ := []string{
+		"APP_DB__HOST=localhost",
+		"APP_LOG_LEVEL=debug",
+		"APP_DB__POOL__MAX=10",
+		"APP_=ignored",
+		"HOME=/root",
+	}
+	want := []Assignment{
+		{Path: "db.host", Operator: OpAssignString, Value: "localhost"},
+		{Path: "db.pool.max", Operator: OpAssignString, Value: "10"},
+		{Path: "log_level", Operator: OpAssignString, Value: "debug"},
+	}
+	if got := EnvAssignments("APP_", environ); !reflect.DeepEqual(got, want) {
+		t.Errorf("EnvAssignments() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,90 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArgumentError reports a problem with one assignment argument.
+type ArgumentError struct {
+	Index int    // Position of the argument, starting at 0
+	Arg   string // The argument as written
+	Err   error
+}
+
+func (e *ArgumentError) Error() string {
+	return fmt.Sprintf("argument %d (%s): %v", e.Index+1, e.Arg, e.Err)
+}
+
+func (e *ArgumentError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError collects every failing argument of an invocation so they
+// can be reported together.
+type ValidationError struct {
+	Errors []*ArgumentError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	lines := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		lines[i] = "  " + err.Error()
+	}
+	return fmt.Sprintf("%d assignments failed:\n%s", len(e.Errors), strings.Join(lines, "\n"))
+}
+
+func (e *ValidationError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+// ParseAllAssignments parses every argument, returning a *ValidationError
+// listing all invalid ones instead of stopping at the first.
+func ParseAllAssignments(args []string) ([]Assignment, error) {
+	var assignments []Assignment
+	var invalid ValidationError
+
+	for i, arg := range args {
+		assignment, err := parseAssignment(arg)
+		if err != nil {
+			invalid.Errors = append(invalid.Errors, &ArgumentError{Index: i, Arg: arg, Err: err})
+			continue
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	if len(invalid.Errors) > 0 {
+		return nil, &invalid
+	}
+	return assignments, nil
+}
+
+// String renders the assignment in argument syntax.
+func (a Assignment) String() string {
+	return a.Path + a.Operator.String() + a.Value
+}
+
+// String returns the operator as written in an argument.
+func (op OperatorType) String() string {
+	switch op {
+	case OpAssignJSON, OpAppendArrayJSON, OpArrayMapJSON:
+		return ":="
+	case OpAssignFile:
+		return "@"
+	case OpAssignJSONFile:
+		return ":@"
+	case OpAssignEnv:
+		return "$="
+	case OpAssignSelf:
+		return ":=@."
+	default:
+		return "="
+	}
+}
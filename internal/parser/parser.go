@@ -17,6 +17,8 @@ const (
 	OpAppendArrayJSON                     // []:=
 	OpArrayMap                            // [].key=
 	OpArrayMapJSON                        // [].key:=
+	OpAssignEnv                           // $=
+	OpAssignSelf                          // :=@.
 )
 
 type Assignment struct {
@@ -25,6 +27,10 @@ type Assignment struct {
 	Value    string
 }
 
+// errEnvArrayPath rejects $= on append and array map paths, which would
+// otherwise be taken as a key ending in $ or [].
+var errEnvArrayPath = errors.New("$= cannot be used to append or with [] paths; assign the variable to a single path")
+
 func ParseAssignments(args []string) ([]Assignment, error) {
 	var assignments []Assignment
 
@@ -52,6 +58,9 @@ func parseAssignment(arg string) (Assignment, error) {
 			}, nil
 		}
 		if opIdx := strings.Index(remaining, "="); opIdx > 0 {
+			if strings.HasSuffix(remaining[:opIdx], "$") {
+				return Assignment{}, errEnvArrayPath
+			}
 			return Assignment{
 				Path:     arg[:idx+3+opIdx], // Include path up to operator
 				Operator: OpArrayMap,
@@ -76,6 +85,18 @@ func parseAssignment(arg string) (Assignment, error) {
 		}, nil
 	}
 
+	// Check for environment variable operator
+	if idx := strings.Index(arg, "$="); idx > 0 && !strings.Contains(arg[:idx], "=") {
+		if strings.HasSuffix(arg[:idx], "[]") {
+			return Assignment{}, errEnvArrayPath
+		}
+		return Assignment{
+			Path:     arg[:idx],
+			Operator: OpAssignEnv,
+			Value:    arg[idx+2:],
+		}, nil
+	}
+
 	// Check for file operators
 	if idx := strings.Index(arg, ":@"); idx > 0 {
 		return Assignment{
@@ -92,8 +113,15 @@ func parseAssignment(arg string) (Assignment, error) {
 		}, nil
 	}
 
-	// Check for JSON operator
+	// Check for JSON operator, where :=@. copies from the document itself
 	if idx := strings.Index(arg, ":="); idx > 0 {
+		if value := arg[idx+2:]; strings.HasPrefix(value, "@.") {
+			return Assignment{
+				Path:     arg[:idx],
+				Operator: OpAssignSelf,
+				Value:    value[2:],
+			}, nil
+		}
 		return Assignment{
 			Path:     arg[:idx],
 			Operator: OpAssignJSON,
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 )
@@ -37,6 +38,39 @@ func TestParseAssignments(t *testing.T) {
 				{Path: "config", Operator: OpAssignJSONFile, Value: "config.json"},
 			},
 		},
+		{
+			name: "environment variable",
+			args: []string{"token$=API_TOKEN", "note=a$=b"},
+			expected: []Assignment{
+				{Path: "token", Operator: OpAssignEnv, Value: "API_TOKEN"},
+				{Path: "note", Operator: OpAssignString, Value: "a$=b"},
+			},
+		},
+		{
+			name:    "environment variable appended to array",
+			args:    []string{"tags[]$=TOK"},
+			wantErr: true,
+		},
+		{
+			name:    "environment variable in array map",
+			args:    []string{"users.[].token$=TOK"},
+			wantErr: true,
+		},
+		{
+			name: "array map value containing $=",
+			args: []string{"users.[].note=a$=b"},
+			expected: []Assignment{
+				{Path: "users.[].note", Operator: OpArrayMap, Value: "a$=b"},
+			},
+		},
+		{
+			name: "copy from document",
+			args: []string{"backup.db:=@.#db", "config:@shared.json#/db"},
+			expected: []Assignment{
+				{Path: "backup.db", Operator: OpAssignSelf, Value: "#db"},
+				{Path: "config", Operator: OpAssignJSONFile, Value: "shared.json#/db"},
+			},
+		},
 		{
 			name: "array append",
 			args: []string{"tags[]=new", "ports[]:=8080"},
@@ -124,3 +158,35 @@ func TestParsePath(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAllAssignments(t *testing.T) {
+	_, err := ParseAllAssignments([]string{"a=1", "bad", "b:=2", "=x"})
+
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %v, want *ValidationError", err)
+	}
+	if len(verr.Errors) != 2 {
+		t.Fatalf("got %d errors, want 2: %v", len(verr.Errors), err)
+	}
+	if verr.Errors[0].Index != 1 || verr.Errors[1].Index != 3 {
+		t.Errorf("indices = %d, %d, want 1, 3", verr.Errors[0].Index, verr.Errors[1].Index)
+	}
+
+	assignments, err := ParseAllAssignments([]string{"a=1", "b:=2"})
+	if err != nil || len(assignments) != 2 {
+		t.Errorf("ParseAllAssignments() = %v, %v", assignments, err)
+	}
+}
+
+func TestAssignmentString(t *testing.T) {
+	for _, arg := range []string{"a=1", "a:=1", "a@f", "a:@f", "a[]=x", "a[]:=1", "a.[].b=x", "a.[].b:=1", "a$=V", "a:=@.#b"} {
+		assignments, err := ParseAssignments([]string{arg})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := assignments[0].String(); got != arg {
+			t.Errorf("String() = %q, want %q", got, arg)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// heredoc matches a value introducing a multi-line heredoc value, such as <<EOF.
+var heredoc = regexp.MustCompile(`^<<([A-Za-z_][A-Za-z0-9_]*)$`)
+
+// ScriptError reports a problem on a line of an assignment script.
+type ScriptError struct {
+	File string
+	Line int
+	Err  error
+}
+
+func (e *ScriptError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.File, e.Line, e.Err)
+}
+
+func (e *ScriptError) Unwrap() error {
+	return e.Err
+}
+
+// ParseScript parses an assignment script: one assignment per line in the
+// same syntax as ParseAssignments. Blank lines and lines starting with #
+// are ignored. A line ending in a backslash continues on the next line,
+// with the next line's leading whitespace dropped. A value of <<WORD takes
+// the following lines, up to a line holding only WORD, as a multi-line
+// value:
+//
+//	description=<<EOF
+//	First line
+//	Second line
+//	EOF
+//
+// name is used in error messages.
+func ParseScript(r io.Reader, name string) ([]Assignment, error) {
+	var assignments []Assignment
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 64<<20)
+	lineNo := 0
+	next := func() (string, bool) {
+		if !scanner.Scan() {
+			return "", false
+		}
+		lineNo++
+		return strings.TrimSuffix(scanner.Text(), "\r"), true
+	}
+
+	for {
+		line, ok := next()
+		if !ok {
+			break
+		}
+		start := lineNo
+
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		for continues(trimmed) {
+			more, ok := next()
+			if !ok {
+				return nil, &ScriptError{File: name, Line: start, Err: errors.New("line continuation at end of script")}
+			}
+			trimmed = trimmed[:len(trimmed)-1] + strings.TrimSpace(more)
+		}
+
+		assignment, err := parseAssignment(trimmed)
+		if err != nil {
+			return nil, &ScriptError{File: name, Line: start, Err: fmt.Errorf("invalid assignment %q: %w", trimmed, err)}
+		}
+
+		if m := heredoc.FindStringSubmatch(assignment.Value); m != nil && assignment.Operator != OpAssignFile && assignment.Operator != OpAssignJSONFile {
+			var body []string
+			for {
+				more, ok := next()
+				if !ok {
+					return nil, &ScriptError{File: name, Line: start, Err: fmt.Errorf("heredoc %s is not terminated", m[1])}
+				}
+				if strings.TrimSpace(more) == m[1] {
+					break
+				}
+				body = append(body, more)
+			}
+			assignment.Value = strings.Join(body, "\n")
+		}
+
+		assignments = append(assignments, assignment)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script %s: %w", name, err)
+	}
+	return assignments, nil
+}
+
+// ParseScriptFile parses the assignment script in filename, or stdin for "-".
+func ParseScriptFile(filename string) ([]Assignment, error) {
+	if filename == "-" {
+		return ParseScript(os.Stdin, "<stdin>")
+	}
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	defer f.Close()
+	return ParseScript(f, filename)
+}
+
+// continues reports whether a line ends in an unescaped backslash.
+func continues(line string) bool {
+	n := len(line) - len(strings.TrimRight(line, `\`))
+	return n%2 == 1
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScript(t *testing.T) {
+	script := `# database settings
+db.host=localhost
+db.port:=5432
+
+tags[]=a b c
+config:={"a": 1, \
+         "b": 2}
+path=C:\\
+description=<<EOF
+First line
+  indented "quoted" line
+EOF
+motd:=<<JSON
+["x",
+ "y"]
+JSON
+`
+	got, err := ParseScript(strings.NewReader(script), "changes.je")
+	if err != nil {
+		t.Fatalf("ParseScript() error = %v", err)
+	}
+	want := []Assignment{
+		{Path: "db.host", Operator: OpAssignString, Value: "localhost"},
+		{Path: "db.port", Operator: OpAssignJSON, Value: "5432"},
+		{Path: "tags[]", Operator: OpAppendArray, Value: "a b c"},
+		{Path: "config", Operator: OpAssignJSON, Value: `{"a": 1, "b": 2}`},
+		{Path: "path", Operator: OpAssignString, Value: `C:\\`},
+		{Path: "description", Operator: OpAssignString, Value: "First line\n  indented \"quoted\" line"},
+		{Path: "motd", Operator: OpAssignJSON, Value: "[\"x\",\n \"y\"]"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseScript() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestParseScriptErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		line   int
+	}{
+		{name: "invalid assignment", script: "a=1\n\nnot an assignment\n", line: 3},
+		{name: "unterminated heredoc", script: "a=1\nb=<<EOF\ntext\n", line: 2},
+		{name: "dangling continuation", script: "a=1 \\", line: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseScript(strings.NewReader(tt.script), "s.je")
+			var scriptErr *ScriptError
+			if !errors.As(err, &scriptErr) {
+				t.Fatalf("error = %v, want *ScriptError", err)
+			}
+			if scriptErr.Line != tt.line {
+				t.Errorf("line = %d, want %d (%v)", scriptErr.Line, tt.line, err)
+			}
+		})
+	}
+}